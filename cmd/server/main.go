@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"database/sql"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
+	"Offline-First/internal/audit"
+	"Offline-First/internal/auth"
+	"Offline-First/internal/blob"
 	"Offline-First/internal/db"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/export"
 	httpapi "Offline-First/internal/http"
 	"Offline-First/internal/http/handler"
 	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/ratelimit"
 	"Offline-First/internal/repository/postgres"
+	"Offline-First/internal/validation"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -36,19 +47,77 @@ func main() {
 	runMigrations(dsn)
 
 	// 4️⃣  Create repository
-	itemRepo := postgres.NewItemRepository(dbConn)
+	quota := loadQuota()
+	itemRepo := postgres.NewItemRepository(dbConn, quota)
+	tagRepo := postgres.NewTagRepository(dbConn)
+	collectionRepo := postgres.NewCollectionRepository(dbConn)
+	linkRepo := postgres.NewLinkRepository(dbConn)
+	blobRepo := postgres.NewBlobRepository(dbConn, quota)
+	attachmentRepo := postgres.NewAttachmentRepository(dbConn)
+	shareRepo := postgres.NewShareRepository(dbConn)
+	workspaceRepo := postgres.NewWorkspaceRepository(dbConn)
+	commentRepo := postgres.NewCommentRepository(dbConn)
+	deviceRepo := postgres.NewDeviceRepository(dbConn)
+	apiKeyRepo := postgres.NewAPIKeyRepository(dbConn)
+	usageRepo := postgres.NewUsageRepository(dbConn)
+	auditRepo := postgres.NewAuditRepository(dbConn)
+	exportRepo := postgres.NewExportJobRepository(dbConn)
+	mutationLogRepo := postgres.NewMutationLogRepository(dbConn)
+	erasureRepo := postgres.NewErasureRepository(dbConn)
+
+	// 📝 Audit log writer for authentication events
+	recorder := audit.NewRecorder(auditRepo, 1024)
+	go recorder.Run(context.Background())
+
+	// 🔑 Device token service
+	tokens := loadTokenService()
+
+	// 📦 Blob storage + background garbage collection
+	store, staging, maxBlobBytes := loadBlobStorage()
+	go blob.NewCollector(blobRepo, store, staging).Run(context.Background(), time.Hour)
+
+	// 🗜️ Account exports, built in the background
+	exporter := loadExportWorker(exportRepo, export.Source{
+		Items:       itemRepo,
+		Collections: collectionRepo,
+		Tags:        tagRepo,
+		Attachments: attachmentRepo,
+		Devices:     deviceRepo,
+		Mutations:   mutationLogRepo,
+	})
+	go exporter.Run(context.Background(), time.Minute)
 
 	// 5️⃣ Create handlers
-	itemHandler := handler.NewItemHandler(itemRepo)
+	handlers := httpapi.Handlers{
+		Items:       handler.NewItemHandler(itemRepo, loadValidation()),
+		Tags:        handler.NewTagHandler(tagRepo),
+		Collections: handler.NewCollectionHandler(collectionRepo),
+		Links:       handler.NewLinkHandler(linkRepo),
+		Sync:        handler.NewSyncHandler(itemRepo, tagRepo, collectionRepo, attachmentRepo, shareRepo, workspaceRepo, commentRepo),
+		Uploads:     handler.NewUploadHandler(blobRepo, store, staging, maxBlobBytes),
+		Attachments: handler.NewAttachmentHandler(attachmentRepo),
+		Shares:      handler.NewShareHandler(shareRepo),
+		Workspaces:  handler.NewWorkspaceHandler(workspaceRepo),
+		Comments:    handler.NewCommentHandler(commentRepo),
+		Devices:     handler.NewDeviceHandler(deviceRepo, tokens, recorder),
+		APIKeys:     handler.NewAPIKeyHandler(apiKeyRepo),
+		Account:     handler.NewAccountHandler(usageRepo, quota, exportRepo, exporter, erasureRepo, store, staging),
+		Audit:       handler.NewAuditHandler(auditRepo),
+	}
 
 	// 6️⃣  Create router
-	router := httpapi.NewRouter(itemHandler)
+	router := httpapi.NewRouter(handlers)
 
-	// 🔐 wrap router with auth
-	securedRouter := middleware.Auth(router)
+	// 🔐 wrap router with auth and per-device rate limits
+	securedRouter := middleware.Auth(
+		loadAuth(tokens, deviceRepo, apiKeyRepo),
+		erasureRepo,
+		recorder,
+		middleware.RateLimit(loadRateLimits(), router),
+	)
 
 	// 7️⃣ Add health endpoint
-	routerWithHealth := addHealth(securedRouter)
+	routerWithHealth := addHealth(httpapi.WithPublicRoutes(handlers, securedRouter))
 
 	// 8️⃣ Start server
 	log.Println("api listening on :8081")
@@ -101,6 +170,289 @@ func runMigrations(dsn string) {
 
 }
 
+/*
+ITEM_SCHEMA_FILE points at a JSON validation config (see validation.Config).
+REJECT_UNKNOWN_ITEM_TYPES=true rejects item types not listed in it.
+*/
+func loadValidation() *validation.Registry {
+	registry := validation.NewRegistry(validation.DefaultRule, true)
+
+	if path := os.Getenv("ITEM_SCHEMA_FILE"); path != "" {
+		loaded, err := validation.LoadRegistry(path)
+		if err != nil {
+			log.Fatalf("failed to load item schema: %v", err)
+		}
+		registry = loaded
+		log.Printf("item schema loaded from %s", path)
+	}
+
+	if os.Getenv("REJECT_UNKNOWN_ITEM_TYPES") == "true" {
+		registry.RejectUnknownTypes()
+	}
+
+	return registry
+}
+
+/*
+BLOB_DIR holds finished blobs (default ./data/blobs), UPLOAD_DIR partial
+uploads (default ./data/uploads). MAX_BLOB_BYTES caps a single upload
+(default 100 MiB).
+*/
+func loadBlobStorage() (*blob.FileStore, *blob.Staging, int64) {
+	blobDir := envOr("BLOB_DIR", "./data/blobs")
+	uploadDir := envOr("UPLOAD_DIR", "./data/uploads")
+
+	maxBlobBytes := int64(100 << 20)
+	if v := os.Getenv("MAX_BLOB_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			log.Fatalf("invalid MAX_BLOB_BYTES: %q", v)
+		}
+		maxBlobBytes = n
+	}
+
+	store, err := blob.NewFileStore(blobDir)
+	if err != nil {
+		log.Fatalf("failed to open blob store: %v", err)
+	}
+	staging, err := blob.NewStaging(uploadDir)
+	if err != nil {
+		log.Fatalf("failed to open upload staging: %v", err)
+	}
+
+	return store, staging, maxBlobBytes
+}
+
+/*
+loadAuth configures bearer-token authentication. Tokens of an external
+identity provider are verified with:
+
+	JWT_HS256_SECRET_FILE   file holding an HS256 secret (>= 32 bytes)
+	JWT_PUBLIC_KEY_FILES    comma-separated PEM public keys (RS256 / ES256)
+	JWT_JWKS_FILE           JWK Set with RS256 / ES256 public keys
+	JWT_ISSUER              required iss (optional)
+	JWT_AUDIENCE            required aud (optional)
+	JWT_USER_CLAIM          claim holding the user ID (default sub)
+	JWT_LEEWAY              tolerated clock skew (default 30s)
+
+Access tokens the server issues to registered devices and API keys
+are always accepted (see loadTokenService).
+
+AUTH_DEV_HEADER=true additionally trusts X-User-ID; never use it in production.
+*/
+func loadAuth(tokens *auth.TokenService, devices auth.DeviceStore, apiKeys auth.APIKeyStore) auth.Authenticator {
+	external := loadExternalVerifier()
+
+	// API keys first: they also arrive as bearer tokens
+	authn := auth.FirstOf{
+		auth.NewAPIKeyAuthenticator(apiKeys),
+		auth.NewBearerAuthenticator(external, tokens, devices),
+	}
+
+	if os.Getenv("AUTH_DEV_HEADER") == "true" {
+		log.Println("WARNING: AUTH_DEV_HEADER=true, trusting X-User-ID without authentication")
+		return append(authn, auth.HeaderAuthenticator{})
+	}
+
+	if external == nil {
+		log.Fatal("no JWT keys configured (set JWT_HS256_SECRET_FILE, JWT_PUBLIC_KEY_FILES or JWT_JWKS_FILE)")
+	}
+	return authn
+}
+
+func loadExternalVerifier() *auth.Verifier {
+	var keys []auth.Key
+
+	if path := os.Getenv("JWT_HS256_SECRET_FILE"); path != "" {
+		key, err := auth.LoadHMACFile(path)
+		if err != nil {
+			log.Fatalf("failed to load JWT secret: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	for _, path := range strings.Split(os.Getenv("JWT_PUBLIC_KEY_FILES"), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		key, err := auth.LoadPEMFile(path)
+		if err != nil {
+			log.Fatalf("failed to load JWT public key: %v", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if path := os.Getenv("JWT_JWKS_FILE"); path != "" {
+		jwks, err := auth.LoadJWKSFile(path)
+		if err != nil {
+			log.Fatalf("failed to load JWKS: %v", err)
+		}
+		keys = append(keys, jwks...)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	verifier, err := auth.NewVerifier(auth.Config{
+		Issuer:    os.Getenv("JWT_ISSUER"),
+		Audience:  os.Getenv("JWT_AUDIENCE"),
+		UserClaim: os.Getenv("JWT_USER_CLAIM"),
+		Leeway:    durationEnv("JWT_LEEWAY", 30*time.Second),
+	}, keys)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("jwt auth enabled with %d key(s)", len(keys))
+	return verifier
+}
+
+/*
+loadTokenService configures the tokens issued to registered devices:
+
+	DEVICE_TOKEN_SECRET_FILE  HS256 signing secret (>= 32 bytes)
+	ACCESS_TOKEN_TTL          access token lifetime (default 15m)
+	REFRESH_TOKEN_TTL         refresh token lifetime (default 720h)
+
+Without a secret file a random one is generated, so access tokens do
+not survive a restart (devices simply refresh).
+*/
+func loadTokenService() *auth.TokenService {
+	var secret []byte
+	if path := os.Getenv("DEVICE_TOKEN_SECRET_FILE"); path != "" {
+		key, err := auth.LoadHMACFile(path)
+		if err != nil {
+			log.Fatalf("failed to load device token secret: %v", err)
+		}
+		secret = key.Key.([]byte)
+	} else {
+		log.Println("DEVICE_TOKEN_SECRET_FILE not set, using an ephemeral device token secret")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("failed to generate device token secret: %v", err)
+		}
+	}
+
+	tokens, err := auth.NewTokenService(
+		secret,
+		durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute),
+		durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return tokens
+}
+
+/*
+EXPORT_DIR holds finished account exports (default ./data/exports);
+EXPORT_TTL is how long they can be downloaded (default 24h).
+*/
+func loadExportWorker(jobs *postgres.ExportJobRepository, source export.Source) *export.Worker {
+	worker, err := export.NewWorker(jobs, source, envOr("EXPORT_DIR", "./data/exports"))
+	if err != nil {
+		log.Fatalf("failed to open export dir: %v", err)
+	}
+	worker.TTL = durationEnv("EXPORT_TTL", 24*time.Hour)
+	return worker
+}
+
+/*
+loadQuota reads the per-user storage quotas; 0 (the default) is unlimited:
+
+	QUOTA_ITEMS             live items
+	QUOTA_CONTENT_BYTES     title + content bytes of live items
+	QUOTA_ATTACHMENT_BYTES  bytes of uploaded blobs
+*/
+func loadQuota() domain.Quota {
+	limit := func(key string) int64 {
+		v := os.Getenv(key)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid %s: %q", key, v)
+		}
+		return n
+	}
+
+	return domain.Quota{
+		Items:           limit("QUOTA_ITEMS"),
+		ContentBytes:    limit("QUOTA_CONTENT_BYTES"),
+		AttachmentBytes: limit("QUOTA_ATTACHMENT_BYTES"),
+	}
+}
+
+/*
+loadRateLimits configures the per device / API key and per user token
+buckets:
+
+	MUTATION_RATE_LIMIT        sustained mutations per second (default 5)
+	MUTATION_BURST             mutations allowed in a burst (default 50)
+	CHANGES_RATE_LIMIT         sustained /changes polls per second (default 1)
+	CHANGES_BURST              /changes polls allowed in a burst (default 10)
+	USER_MUTATION_RATE_LIMIT   sustained mutations per second per user (default 10)
+	USER_MUTATION_BURST        mutations per user in a burst (default 100)
+	USER_CHANGES_RATE_LIMIT    sustained /changes polls per second per user (default 3)
+	USER_CHANGES_BURST         /changes polls per user in a burst (default 30)
+
+A rate of 0 disables that limit.
+*/
+func loadRateLimits() middleware.RateLimits {
+	return middleware.RateLimits{
+		Mutations: ratelimit.NewLimiter(floatEnv("MUTATION_RATE_LIMIT", 5), intEnv("MUTATION_BURST", 50)),
+		Changes:   ratelimit.NewLimiter(floatEnv("CHANGES_RATE_LIMIT", 1), intEnv("CHANGES_BURST", 10)),
+
+		UserMutations: ratelimit.NewLimiter(floatEnv("USER_MUTATION_RATE_LIMIT", 10), intEnv("USER_MUTATION_BURST", 100)),
+		UserChanges:   ratelimit.NewLimiter(floatEnv("USER_CHANGES_RATE_LIMIT", 3), intEnv("USER_CHANGES_BURST", 30)),
+	}
+}
+
+func floatEnv(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 {
+		log.Fatalf("invalid %s: %q", key, v)
+	}
+	return f
+}
+
+func intEnv(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		log.Fatalf("invalid %s: %q", key, v)
+	}
+	return n
+}
+
+func durationEnv(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Fatalf("invalid %s: %q", key, v)
+	}
+	return d
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
 func addHealth(next http.Handler) http.Handler {
 	mux := http.NewServeMux()
 
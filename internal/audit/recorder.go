@@ -0,0 +1,101 @@
+package audit
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"log"
+	"time"
+)
+
+// Sink persists audit events.
+type Sink interface {
+	Append(ctx context.Context, events ...*domain.AuditEvent) error
+}
+
+/*
+Recorder writes events from the request path (authentication) in
+batches, so auditing every request does not take a second pool
+connection per request.
+
+Mutations and credential changes do not go through the Recorder: they
+are written inside their own transaction.
+*/
+type Recorder struct {
+	sink   Sink
+	events chan *domain.AuditEvent
+
+	// BatchSize caps the events written per transaction.
+	BatchSize int
+	// FlushInterval bounds how long an event waits in the buffer.
+	FlushInterval time.Duration
+}
+
+func NewRecorder(sink Sink, buffer int) *Recorder {
+	return &Recorder{
+		sink:          sink,
+		events:        make(chan *domain.AuditEvent, buffer),
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	}
+}
+
+/*
+Record queues e. It blocks while the buffer is full rather than drop
+events, which slows requests down when the database falls behind. A nil
+Recorder discards events.
+*/
+func (r *Recorder) Record(e *domain.AuditEvent) {
+	if r == nil {
+		return
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now()
+	}
+	if e.Outcome == "" {
+		e.Outcome = domain.AuditSuccess
+	}
+	r.events <- e
+}
+
+// Run writes queued events until ctx is cancelled, then flushes what is left.
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*domain.AuditEvent, 0, r.BatchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.sink.Append(ctx, batch...); err != nil {
+			// keep a trace of what could not be stored
+			log.Printf("audit: failed to write %d events: %v", len(batch), err)
+			for _, e := range batch {
+				log.Printf("audit: lost %s/%s %s user=%s remote=%s", e.Category, e.Action, e.Outcome, e.UserID, e.RemoteAddr)
+			}
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case e := <-r.events:
+			batch = append(batch, e)
+			if len(batch) >= r.BatchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		case <-ctx.Done():
+			for {
+				select {
+				case e := <-r.events:
+					batch = append(batch, e)
+				default:
+					flush(context.Background())
+					return
+				}
+			}
+		}
+	}
+}
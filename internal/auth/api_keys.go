@@ -0,0 +1,103 @@
+package auth
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// APIKeyScopes are the scopes an API key can be restricted to.
+var APIKeyScopes = []string{ScopeItemsRead, ScopeItemsWrite, ScopeChangesRead}
+
+const apiKeyPrefix = "ofk_"
+
+// APIKeyStore looks up keys by their public prefix.
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, prefix string) (*domain.APIKey, error)
+	// TouchAPIKey records a use; implementations may coarsen the timestamp.
+	TouchAPIKey(ctx context.Context, id string) error
+}
+
+/*
+NewAPIKey returns a new key and the parts that are stored: its prefix
+and the hash of its secret. The key itself is shown to the user once.
+*/
+func NewAPIKey() (key, prefix, hash string, err error) {
+	raw := make([]byte, 6+32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", "", err
+	}
+	prefix = hex.EncodeToString(raw[:6])
+	secret := hex.EncodeToString(raw[6:])
+	return apiKeyPrefix + prefix + "_" + secret, prefix, HashSecret(secret), nil
+}
+
+func parseAPIKey(key string) (prefix, secret string, ok bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", "", false
+	}
+	prefix, secret, ok = strings.Cut(rest, "_")
+	return prefix, secret, ok && prefix != "" && secret != ""
+}
+
+/*
+APIKeyAuthenticator accepts API keys sent as "Authorization: Bearer
+ofk_..." or in the X-API-Key header. Other credentials are left to
+the next authenticator.
+*/
+type APIKeyAuthenticator struct {
+	keys APIKeyStore
+}
+
+func NewAPIKeyAuthenticator(keys APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{keys: keys}
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		if token, ok := BearerToken(r); ok && strings.HasPrefix(token, apiKeyPrefix) {
+			key = token
+		}
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	prefix, secret, ok := parseAPIKey(key)
+	if !ok {
+		return nil, invalid("malformed API key")
+	}
+
+	stored, err := a.keys.LookupAPIKey(r.Context(), prefix)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, invalid("unknown API key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.Hash), []byte(HashSecret(secret))) != 1 {
+		return nil, invalid("unknown API key")
+	}
+	if stored.Revoked() {
+		return nil, invalid("API key revoked")
+	}
+
+	if err := a.keys.TouchAPIKey(r.Context(), stored.ID); err != nil {
+		return nil, err
+	}
+
+	// an unscoped key holds every key scope, never account:manage
+	p := &Principal{UserID: stored.UserID, APIKeyID: stored.ID, Scopes: slices.Clone(APIKeyScopes)}
+	if len(stored.Scopes) > 0 {
+		p.Scopes = slices.Clone(stored.Scopes)
+	}
+	return p, nil
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ErrNoCredentials is returned when a request carries no credentials at all.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string
+	// DeviceID is set for access tokens issued to a registered device.
+	DeviceID string
+	// APIKeyID is set when the caller used an API key.
+	APIKeyID string
+	// Scopes restricts the caller; nil means every scope but admin.
+	Scopes []string
+}
+
+// Authenticator identifies the caller of a request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// DeviceStore reports whether a registered device may still be used;
+// it fails with domain.ErrAccountDeleted once its account is erased.
+type DeviceStore interface {
+	DeviceActive(ctx context.Context, userID, deviceID string) (bool, error)
+}
+
+// ErasureStore reports whether a user's account was erased.
+type ErasureStore interface {
+	AccountErased(ctx context.Context, userID string) (bool, error)
+}
+
+/*
+BearerAuthenticator accepts "Authorization: Bearer <jwt>".
+
+Tokens issued to a device by the TokenService are checked against the
+device store on every request, so revoking a device takes effect
+before its access token expires. Any other token goes to the external
+verifier, which may be nil when only device tokens are accepted.
+*/
+type BearerAuthenticator struct {
+	external *Verifier
+	tokens   *TokenService
+	devices  DeviceStore
+}
+
+func NewBearerAuthenticator(external *Verifier, tokens *TokenService, devices DeviceStore) *BearerAuthenticator {
+	return &BearerAuthenticator{external: external, tokens: tokens, devices: devices}
+}
+
+func (b *BearerAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := BearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	if IsDeviceToken(token) {
+		p, err := b.tokens.Verify(token)
+		if err != nil {
+			return nil, err
+		}
+		active, err := b.devices.DeviceActive(r.Context(), p.UserID, p.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, domain.ErrDeviceRevoked
+		}
+		return p, nil
+	}
+
+	if b.external == nil {
+		return nil, invalid("unknown token issuer")
+	}
+	userID, claims, err := b.external.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{UserID: userID, Scopes: scopesFromClaims(claims)}, nil
+}
+
+// BearerToken extracts the token of a Bearer Authorization header.
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+/*
+HeaderAuthenticator trusts the X-User-ID header as is.
+
+Any caller can claim any user, so it is only wired in when the
+server runs with AUTH_DEV_HEADER=true.
+*/
+type HeaderAuthenticator struct{}
+
+func (HeaderAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	userID := r.Header.Get("X-User-ID")
+	if userID == "" {
+		return nil, ErrNoCredentials
+	}
+	return &Principal{UserID: userID}, nil
+}
+
+// FirstOf tries each authenticator until one finds credentials.
+type FirstOf []Authenticator
+
+func (f FirstOf) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range f {
+		p, err := a.Authenticate(r)
+		if !errors.Is(err, ErrNoCredentials) {
+			return p, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
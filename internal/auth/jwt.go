@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken wraps every reason a token is rejected.
+var ErrInvalidToken = errors.New("invalid token")
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidToken, fmt.Sprintf(format, args...))
+}
+
+// Config holds the claim checks applied to every token.
+type Config struct {
+	Issuer    string        // required iss; empty skips the check
+	Audience  string        // must appear in aud; empty skips the check
+	UserClaim string        // claim holding the user ID (default "sub")
+	Leeway    time.Duration // clock skew tolerated on exp / nbf
+}
+
+/*
+Verifier checks JWS compact tokens signed with HS256, RS256 or ES256.
+
+It is read-only after construction and safe for concurrent use.
+*/
+type Verifier struct {
+	cfg  Config
+	keys []Key
+	now  func() time.Time
+}
+
+func NewVerifier(cfg Config, keys []Key) (*Verifier, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("auth: no verification keys configured")
+	}
+	if cfg.UserClaim == "" {
+		cfg.UserClaim = "sub"
+	}
+	return &Verifier{cfg: cfg, keys: keys, now: time.Now}, nil
+}
+
+// Claims is the decoded payload of a verified token.
+type Claims map[string]any
+
+// String returns a string claim, or "" when absent or not a string.
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+/*
+Verify checks the signature and the registered claims and returns the
+user ID together with all claims.
+
+exp is required; nbf is honoured when present. The alg header must
+match the type of the key that verifies it, so an RSA public key can
+never be used as an HMAC secret.
+*/
+func (v *Verifier) Verify(token string) (string, Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", nil, invalid("malformed token")
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return "", nil, invalid("malformed header")
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, invalid("malformed signature")
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+	if !v.verifySignature(h, signed, sig) {
+		return "", nil, invalid("signature verification failed")
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", nil, invalid("malformed claims")
+	}
+	if err := v.checkClaims(claims); err != nil {
+		return "", nil, err
+	}
+
+	userID := claims.String(v.cfg.UserClaim)
+	if userID == "" {
+		return "", nil, invalid("missing %s claim", v.cfg.UserClaim)
+	}
+	return userID, claims, nil
+}
+
+func (v *Verifier) verifySignature(h header, signed, sig []byte) bool {
+	for _, k := range v.keys {
+		if k.Algorithm != h.Alg || (k.ID != "" && h.Kid != "" && k.ID != h.Kid) {
+			continue
+		}
+		if verifyWith(k, signed, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+func verifyWith(k Key, signed, sig []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch key := k.Key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, key)
+		mac.Write(signed)
+		return hmac.Equal(sig, mac.Sum(nil))
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+	case *ecdsa.PublicKey:
+		// JWS uses the fixed-size r || s encoding, not ASN.1
+		if len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	default:
+		return false
+	}
+}
+
+func (v *Verifier) checkClaims(c Claims) error {
+	now := v.now()
+
+	exp, ok := numericDate(c["exp"])
+	if !ok {
+		return invalid("missing exp claim")
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return invalid("token expired")
+	}
+
+	if raw, present := c["nbf"]; present {
+		nbf, ok := numericDate(raw)
+		if !ok {
+			return invalid("malformed nbf claim")
+		}
+		if now.Add(v.cfg.Leeway).Before(nbf) {
+			return invalid("token not yet valid")
+		}
+	}
+
+	if v.cfg.Issuer != "" && c.String("iss") != v.cfg.Issuer {
+		return invalid("unexpected issuer")
+	}
+
+	if v.cfg.Audience != "" && !slices.Contains(audiences(c["aud"]), v.cfg.Audience) {
+		return invalid("unexpected audience")
+	}
+	return nil
+}
+
+// numericDate reads a JSON number of seconds since the epoch.
+func numericDate(raw any) (time.Time, bool) {
+	n, ok := raw.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	sec := int64(n)
+	return time.Unix(sec, int64((n-float64(sec))*float64(time.Second))), true
+}
+
+// audiences accepts aud as a single string or an array of strings.
+func audiences(raw any) []string {
+	switch aud := raw.(type) {
+	case string:
+		return []string{aud}
+	case []any:
+		out := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
+func decodeSegment(seg string, dst any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
@@ -0,0 +1,211 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported JWT signing algorithms.
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+/*
+Key is one verification key.
+
+Key holds []byte for HS256, *rsa.PublicKey for RS256 and
+*ecdsa.PublicKey (P-256) for ES256. An empty ID matches tokens
+with any (or no) kid header.
+*/
+type Key struct {
+	ID        string
+	Algorithm string
+	Key       any
+}
+
+// minHMACSecret is the shortest HS256 secret accepted (RFC 7518 §3.2).
+const minHMACSecret = 32
+
+// LoadHMACFile reads an HS256 secret; surrounding whitespace is ignored.
+func LoadHMACFile(path string) (Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+	secret := []byte(strings.TrimSpace(string(raw)))
+	if len(secret) < minHMACSecret {
+		return Key{}, fmt.Errorf("%s: HS256 secret must be at least %d bytes", path, minHMACSecret)
+	}
+	return Key{Algorithm: HS256, Key: secret}, nil
+}
+
+/*
+LoadPEMFile reads an RSA or P-256 public key from a PEM file holding a
+PUBLIC KEY, RSA PUBLIC KEY or CERTIFICATE block. The algorithm follows
+from the key type and the file name (without extension) becomes the kid.
+*/
+func LoadPEMFile(path string) (Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Key{}, err
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return Key{}, fmt.Errorf("%s: no PEM block", path)
+	}
+
+	var pub any
+	switch block.Type {
+	case "PUBLIC KEY":
+		pub, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			pub = cert.PublicKey
+		}
+	default:
+		return Key{}, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	key, err := publicKey(id, pub)
+	if err != nil {
+		return Key{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+func publicKey(id string, pub any) (Key, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return Key{}, errors.New("RSA keys must be at least 2048 bits")
+		}
+		return Key{ID: id, Algorithm: RS256, Key: k}, nil
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return Key{}, errors.New("EC keys must use P-256")
+		}
+		return Key{ID: id, Algorithm: ES256, Key: k}, nil
+	default:
+		return Key{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+/*
+LoadJWKSFile reads the RSA and EC public keys of a JWK Set. Keys
+marked for encryption ("use": "enc") or for another algorithm are
+skipped; symmetric keys are never read from a JWKS.
+*/
+func LoadJWKSFile(path string) ([]Key, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var keys []Key
+	for i, k := range set.Keys {
+		if k.Use == "enc" {
+			continue
+		}
+
+		var pub any
+		switch k.Kty {
+		case "RSA":
+			pub, err = rsaFromJWK(k)
+		case "EC":
+			pub, err = ecFromJWK(k)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d: %w", path, i, err)
+		}
+
+		key, err := publicKey(k.Kid, pub)
+		if err != nil {
+			return nil, fmt.Errorf("%s: key %d: %w", path, i, err)
+		}
+		if k.Alg != "" && k.Alg != key.Algorithm {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no usable signing keys", path)
+	}
+	return keys, nil
+}
+
+func rsaFromJWK(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func ecFromJWK(k jwk) (*ecdsa.PublicKey, error) {
+	if k.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, errX := base64.RawURLEncoding.DecodeString(k.X)
+	y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, errors.New("invalid EC coordinates")
+	}
+
+	// uncompressed point encoding: 0x04 || X || Y
+	point := append(append([]byte{4}, x...), y...)
+	pub, err := ecdsa.ParseUncompressedPublicKey(elliptic.P256(), point)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EC point: %w", err)
+	}
+	return pub, nil
+}
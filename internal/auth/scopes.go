@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"slices"
+	"strings"
+)
+
+/*
+Scopes gate routes. Callers without explicit scopes (device tokens,
+identity-provider tokens without a scope claim) get every scope except
+ScopeAdmin, which is only ever granted explicitly. API keys are always
+scoped; an unscoped key holds APIKeyScopes.
+*/
+const (
+	ScopeItemsRead     = "items:read"  // read vault content
+	ScopeItemsWrite    = "items:write" // mutate vault content
+	ScopeChangesRead   = "changes:read"
+	ScopeAccountManage = "account:manage" // devices, API keys, account
+	ScopeAdmin         = "admin"
+)
+
+/*
+Allows reports whether the principal may use scope. The empty scope is
+never granted to scoped principals, so routes without a scope stay
+closed to restricted credentials.
+*/
+func (p *Principal) Allows(scope string) bool {
+	if p.Scopes == nil {
+		return scope != ScopeAdmin
+	}
+	return scope != "" && slices.Contains(p.Scopes, scope)
+}
+
+/*
+scopesFromClaims reads the OAuth "scope" claim (space-separated) or the
+"scp" claim (string or array). nil means the token carries no scope
+claim and is unrestricted.
+*/
+func scopesFromClaims(c Claims) []string {
+	if raw, ok := c["scope"].(string); ok {
+		return strings.Fields(raw)
+	}
+
+	switch scp := c["scp"].(type) {
+	case string:
+		return strings.Fields(scp)
+	case []any:
+		scopes := make([]string, 0, len(scp))
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+		return scopes
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Issued access tokens carry this kid and issuer so they are never
+// confused with tokens of an external identity provider.
+const (
+	DeviceKeyID  = "device"
+	DeviceIssuer = "offline-first"
+	// DeviceClaim holds the device ID in issued access tokens.
+	DeviceClaim = "did"
+)
+
+/*
+TokenService issues the server's own credentials for registered
+devices: short-lived HS256 access tokens bound to a device, and opaque
+refresh tokens of which only a hash is stored.
+*/
+type TokenService struct {
+	secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	verifier   *Verifier
+	now        func() time.Time
+}
+
+func NewTokenService(secret []byte, accessTTL, refreshTTL time.Duration) (*TokenService, error) {
+	if len(secret) < minHMACSecret {
+		return nil, errors.New("auth: device token secret must be at least 32 bytes")
+	}
+
+	verifier, err := NewVerifier(Config{
+		Issuer:   DeviceIssuer,
+		Audience: DeviceIssuer,
+	}, []Key{{ID: DeviceKeyID, Algorithm: HS256, Key: secret}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenService{
+		secret:     secret,
+		AccessTTL:  accessTTL,
+		RefreshTTL: refreshTTL,
+		verifier:   verifier,
+		now:        time.Now,
+	}, nil
+}
+
+// IssueAccessToken returns a signed access token for one device.
+func (s *TokenService) IssueAccessToken(userID, deviceID string) (string, error) {
+	now := s.now()
+
+	h, _ := json.Marshal(header{Alg: HS256, Kid: DeviceKeyID, Typ: "JWT"})
+	c, err := json.Marshal(map[string]any{
+		"iss":       DeviceIssuer,
+		"aud":       DeviceIssuer,
+		"sub":       userID,
+		DeviceClaim: deviceID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(s.AccessTTL).Unix(),
+		"jti":       uuid.NewString(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify checks an access token issued by IssueAccessToken.
+func (s *TokenService) Verify(token string) (*Principal, error) {
+	userID, claims, err := s.verifier.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	deviceID := claims.String(DeviceClaim)
+	if deviceID == "" {
+		return nil, invalid("missing %s claim", DeviceClaim)
+	}
+	return &Principal{UserID: userID, DeviceID: deviceID}, nil
+}
+
+/*
+NewRefreshToken returns a fresh token and its stored form. The token
+handed to the client is "<id>.<secret>"; only the SHA-256 of the
+secret is kept, so a database leak does not leak usable tokens.
+*/
+func (s *TokenService) NewRefreshToken(deviceID string) (string, *domain.RefreshToken, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	stored := &domain.RefreshToken{
+		ID:        uuid.NewString(),
+		DeviceID:  deviceID,
+		Hash:      HashSecret(encoded),
+		ExpiresAt: s.now().Add(s.RefreshTTL),
+	}
+	return stored.ID + "." + encoded, stored, nil
+}
+
+// ParseRefreshToken splits a client token into its ID and secret hash.
+func ParseRefreshToken(token string) (id, hash string, ok bool) {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return "", "", false
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return "", "", false
+	}
+	return id, HashSecret(secret), true
+}
+
+// HashSecret is the at-rest form of a high-entropy secret.
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsDeviceToken reports whether a token claims to be one the service issued.
+func IsDeviceToken(token string) bool {
+	seg, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	var h header
+	return decodeSegment(seg, &h) == nil && h.Kid == DeviceKeyID
+}
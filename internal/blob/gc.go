@@ -0,0 +1,92 @@
+package blob
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"log"
+	"time"
+)
+
+// OrphanSource removes unreferenced rows and reports what to delete from storage.
+type OrphanSource interface {
+	// DeleteOrphanBlobs deletes blobs created before cutoff that no live
+	// attachment references, returning the deleted rows.
+	DeleteOrphanBlobs(ctx context.Context, cutoff time.Time) ([]*domain.Blob, error)
+	// DeleteExpiredUploads deletes upload sessions created before cutoff.
+	DeleteExpiredUploads(ctx context.Context, cutoff time.Time) ([]string, error)
+}
+
+/*
+Collector garbage-collects orphaned blobs and abandoned uploads.
+
+Rows are deleted first (in the database, atomically with the orphan
+check), bytes second; a crash in between only leaks bytes, it never
+leaves a row pointing at missing data.
+*/
+type Collector struct {
+	source  OrphanSource
+	store   Store
+	staging *Staging
+
+	// Grace keeps fresh blobs alive between upload and attach.
+	Grace time.Duration
+	// UploadTTL is how long an unfinished upload may stay resumable.
+	UploadTTL time.Duration
+}
+
+func NewCollector(source OrphanSource, store Store, staging *Staging) *Collector {
+	return &Collector{
+		source:    source,
+		store:     store,
+		staging:   staging,
+		Grace:     24 * time.Hour,
+		UploadTTL: 24 * time.Hour,
+	}
+}
+
+func (c *Collector) Collect(ctx context.Context) error {
+	now := time.Now()
+
+	orphans, err := c.source.DeleteOrphanBlobs(ctx, now.Add(-c.Grace))
+	if err != nil {
+		return err
+	}
+	for _, b := range orphans {
+		if err := c.store.Delete(ctx, Key(b.UserID, b.SHA256)); err != nil {
+			log.Printf("blob gc: delete %s/%s: %v", b.UserID, b.SHA256, err)
+		}
+	}
+
+	expired, err := c.source.DeleteExpiredUploads(ctx, now.Add(-c.UploadTTL))
+	if err != nil {
+		return err
+	}
+	for _, id := range expired {
+		if err := c.staging.Remove(id); err != nil {
+			log.Printf("blob gc: remove upload %s: %v", id, err)
+		}
+	}
+
+	if len(orphans) > 0 || len(expired) > 0 {
+		log.Printf("blob gc: removed %d orphaned blobs, %d expired uploads", len(orphans), len(expired))
+	}
+	return nil
+}
+
+// Run collects every interval until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Collect(ctx); err != nil {
+			log.Printf("blob gc failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+/*
+Staging holds partially uploaded blobs on local disk until every byte
+has arrived and the digest is verified; only then is the blob handed
+to the Store.
+*/
+type Staging struct {
+	dir string
+}
+
+func NewStaging(dir string) (*Staging, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &Staging{dir: dir}, nil
+}
+
+func (s *Staging) path(uploadID string) (string, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return "", errors.New("invalid upload id")
+	}
+	return filepath.Join(s.dir, uploadID), nil
+}
+
+/*
+WriteAt writes a chunk starting at offset. Re-sending the same chunk
+(a retried request) overwrites identical bytes, so it is idempotent.
+*/
+func (s *Staging) WriteAt(uploadID string, offset int64, r io.Reader) (int64, error) {
+	path, err := s.path(uploadID)
+	if err != nil {
+		return 0, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY, 0o640)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, r)
+	if err != nil {
+		return n, err
+	}
+	return n, f.Sync()
+}
+
+// Digest returns the lowercase hex SHA-256 of the staged bytes.
+func (s *Staging) Digest(uploadID string) (string, error) {
+	f, err := s.Open(uploadID)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *Staging) Open(uploadID string) (*os.File, error) {
+	path, err := s.path(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *Staging) Remove(uploadID string) error {
+	path, err := s.path(uploadID)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+/*
+Store is the pluggable backend holding blob bytes.
+
+Keys are produced by Key and are safe path segments. Put must be
+atomic: a reader never observes a partially written blob.
+*/
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Key addresses a blob; blobs are deduplicated per user, not globally.
+func Key(userID, sha256 string) string {
+	return userID + "/" + sha256
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+/*
+APIKey grants non-interactive access on behalf of a user.
+
+The key is "ofk_<prefix>_<secret>"; Prefix identifies it in lists and
+logs, and only the hash of the secret is stored. An empty Scopes list
+means the key can do anything its user can.
+*/
+type APIKey struct {
+	ID         string
+	UserID     string
+	Name       string
+	Prefix     string
+	Hash       string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
+
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
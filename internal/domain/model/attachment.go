@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// Blob is an uploaded file, content-addressed and deduplicated per user.
+type Blob struct {
+	UserID      string
+	SHA256      string // lowercase hex
+	Size        int64
+	ContentType string
+	CreatedAt   time.Time
+}
+
+// Upload is a resumable upload session for a blob not stored yet.
+type Upload struct {
+	ID          string
+	UserID      string
+	SHA256      string // expected digest, verified on completion
+	Size        int64
+	Received    int64
+	ContentType string
+	CreatedAt   time.Time
+}
+
+func (u *Upload) Complete() bool {
+	return u.Received == u.Size
+}
+
+// Attachment links a blob to an item. Synced and versioned like items;
+// removing an attachment leaves a tombstone.
+type Attachment struct {
+	ID          string
+	UserID      string
+	ItemID      string
+	SHA256      string
+	Filename    string
+	ContentType string
+	Size        int64
+	Version     int
+	Deleted     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+/*
+AuditEvent is one row of the append-only audit log.
+
+Mutations, permission and credential changes are written in the same
+transaction as the change itself; authentication events are written
+by the request path after the fact.
+*/
+type AuditEvent struct {
+	ID         int64
+	OccurredAt time.Time
+
+	Category string // auth | mutation | permission | credential | admin | account
+	Action   string // e.g. "bearer", "update", "device.revoke"
+	Outcome  string // success | failure
+
+	UserID     string
+	DeviceID   string
+	APIKeyID   string
+	RemoteAddr string
+
+	EntityType string
+	EntityID   string
+	MutationID string
+	Version    int
+
+	Detail map[string]any
+}
+
+const (
+	AuditAuth       = "auth"
+	AuditMutation   = "mutation"
+	AuditPermission = "permission"
+	AuditCredential = "credential"
+	AuditAdmin      = "admin"
+	AuditAccount    = "account"
+)
+
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+// Collection is a folder of items.
+type Collection struct {
+	ID     string
+	UserID string
+	// ParentID is nil for top-level collections. On Update, nil keeps
+	// the current parent and "" moves the collection to the top level.
+	ParentID  *string
+	Name      string
+	Version   int
+	Deleted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+/*
+CycleConflictError is returned when a move would make a collection
+its own ancestor (typically two offline devices moving folders into
+each other).
+
+The server resolves it deterministically: the moved collection is
+reparented to the top level under a new version, and the client is
+told to reconcile with Collection.
+*/
+type CycleConflictError struct {
+	Collection *Collection
+}
+
+func (e *CycleConflictError) Error() string {
+	return "collection move would create a cycle"
+}
+
+func (e *CycleConflictError) IsRetryable() bool {
+	return false
+}
+
+func (e *CycleConflictError) IsConflict() bool {
+	return true
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+/*
+Comment is a note on an item by anyone who can read it.
+
+Replies point at their parent through ParentID. Deleting keeps the row
+as a tombstone with an empty body so replies keep their place in the
+thread. Comments share the item's version counter (global, or the
+team workspace's), so they arrive in the same /changes feed as the item.
+*/
+type Comment struct {
+	ID        string
+	ItemID    string
+	UserID    string
+	ParentID  *string
+	Body      string
+	Version   int
+	Deleted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
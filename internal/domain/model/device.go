@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+/*
+Device is a client installation registered by a user.
+
+Its access tokens are bound to it and its refresh tokens rotate on
+every use. Revoking sets RevokedAt; the row is kept so the device's
+outstanding tokens keep failing with ErrDeviceRevoked.
+*/
+type Device struct {
+	ID         string
+	UserID     string
+	Name       string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	RevokedAt  *time.Time
+}
+
+func (d *Device) Revoked() bool {
+	return d.RevokedAt != nil
+}
+
+// RefreshToken is the stored form of a refresh token: only the hash
+// of its secret is kept.
+type RefreshToken struct {
+	ID        string
+	DeviceID  string
+	Hash      string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+/*
+Erasure is the proof kept after an account is erased: the user only
+appears as SubjectHash, next to the number of rows removed per table.
+
+Blobs, UploadIDs and ExportIDs name the files the deleted rows pointed
+at; they are removed from storage once the erasure is committed.
+*/
+type Erasure struct {
+	SubjectHash string
+	ErasedAt    time.Time
+	DeletedRows map[string]int64
+
+	Blobs     []*Blob
+	UploadIDs []string
+	ExportIDs []string
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "errors"
+
 /*
 MutationError is a semantic contract between backend and client.
 
@@ -60,6 +62,108 @@ func (e alreadyExistError) IsConflict() bool {
 
 var ErrAlreadyExists = alreadyExistError{}
 
+/*
+========================
+
+	Forbidden
+
+========================
+
+The caller can see the entity but its role does not allow the mutation.
+*/
+type forbiddenError struct{}
+
+func (e forbiddenError) Error() string {
+	return "forbidden"
+}
+
+func (e forbiddenError) IsRetryable() bool {
+	return false
+}
+
+func (e forbiddenError) IsConflict() bool {
+	return false
+}
+
+var ErrForbidden = forbiddenError{}
+
+/*
+========================
+
+	Unauthorized
+
+========================
+
+Credentials were presented but are unknown, expired or malformed.
+*/
+type unauthorizedError struct{}
+
+func (e unauthorizedError) Error() string {
+	return "unauthorized"
+}
+
+func (e unauthorizedError) IsRetryable() bool {
+	return false
+}
+
+func (e unauthorizedError) IsConflict() bool {
+	return false
+}
+
+var ErrUnauthorized = unauthorizedError{}
+
+/*
+========================
+
+	Device Revoked
+
+========================
+
+The device the credentials were issued to has been revoked; the client
+must sign in again instead of refreshing.
+*/
+type deviceRevokedError struct{}
+
+func (e deviceRevokedError) Error() string {
+	return "device revoked"
+}
+
+func (e deviceRevokedError) IsRetryable() bool {
+	return false
+}
+
+func (e deviceRevokedError) IsConflict() bool {
+	return false
+}
+
+var ErrDeviceRevoked = deviceRevokedError{}
+
+/*
+========================
+
+	Account Deleted
+
+========================
+
+The account the credentials belong to has been erased. Signing in
+again does not bring its data back.
+*/
+type accountDeletedError struct{}
+
+func (e accountDeletedError) Error() string {
+	return "account deleted"
+}
+
+func (e accountDeletedError) IsRetryable() bool {
+	return false
+}
+
+func (e accountDeletedError) IsConflict() bool {
+	return false
+}
+
+var ErrAccountDeleted = accountDeletedError{}
+
 /*
 ========================
 
@@ -69,12 +173,20 @@ var ErrAlreadyExists = alreadyExistError{}
 */
 type ConflictError struct {
 	ServerItem *Item
+
+	// ServerState holds the server copy of non-item entities
+	// (*Tag, *ItemTag, ...). Nil for item conflicts.
+	ServerState any
 }
 
 func NewConflictError(item *Item) *ConflictError {
 	return &ConflictError{ServerItem: item}
 }
 
+func NewEntityConflictError(state any) *ConflictError {
+	return &ConflictError{ServerState: state}
+}
+
 func (e *ConflictError) Error() string {
 	return "version conflict"
 }
@@ -125,6 +237,56 @@ func Retryable(err error) error {
 	return retryableError{err: err}
 }
 
+/*
+========================
+
+	Validation Error
+
+========================
+
+Returned when a mutation payload is rejected before it reaches storage.
+Never retryable: replaying the same payload will fail the same way.
+*/
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// OrNil returns nil when no field errors were collected.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return "validation failed"
+	}
+	return "validation failed: " + e.Fields[0].Field + " " + e.Fields[0].Message
+}
+
+func (e *ValidationError) IsRetryable() bool {
+	return false
+}
+
+func (e *ValidationError) IsConflict() bool {
+	return false
+}
+
 /*
 ========================
 
@@ -133,6 +295,7 @@ func Retryable(err error) error {
 ========================
 */
 func IsMutationError(err error) (MutationError, bool) {
-	me, ok := err.(MutationError)
+	var me MutationError
+	ok := errors.As(err, &me)
 	return me, ok
 }
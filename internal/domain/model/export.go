@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// Export job statuses.
+const (
+	ExportPending  = "pending"
+	ExportRunning  = "running"
+	ExportComplete = "complete"
+	ExportFailed   = "failed"
+)
+
+/*
+ExportJob is a background export of everything a user stores. The
+archive can be downloaded once the job is complete and until ExpiresAt.
+*/
+type ExportJob struct {
+	ID          string
+	UserID      string
+	Status      string
+	Error       string
+	Size        int64
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   *time.Time
+}
+
+// MutationLogEntry is one applied mutation as recorded in mutation_log.
+type MutationLogEntry struct {
+	MutationID   string
+	UserID       string
+	EntityType   string
+	EntityID     string
+	MutationType string
+	Version      int
+	AppliedAt    time.Time
+}
@@ -3,13 +3,86 @@ package domain
 import "time"
 
 type Item struct {
-	ID        string
-	UserID    string
-	Type      string
-	Title     string
-	Content   string
-	Version   int
-	Deleted   bool
+	ID     string
+	UserID string
+	// WorkspaceID equals UserID for items in the personal workspace.
+	WorkspaceID string
+	// Personal is stored with the row; access checks never infer it
+	// from the IDs, which a team workspace could otherwise collide with.
+	Personal bool
+	Type     string
+	Title    string
+	Content  string
+	Metadata map[string]any
+	// CollectionID is nil for items at the top level. On Update, nil
+	// keeps the current collection and "" moves the item to the top level.
+	CollectionID *string
+	// Position is the fractional ordering key within the item's list
+	// (its collection, or the workspace top level); "" when unplaced.
+	Position string
+	// Encryption is nil for plaintext items.
+	Encryption *Encryption
+	Version    int
+	Deleted    bool
+	// Purged tombstones keep only their identity; content is gone for good.
+	Purged    bool
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
+
+/*
+Encryption is the envelope of an end-to-end encrypted item.
+
+Title and Content then hold base64 ciphertext the server cannot read.
+Each field has its own nonce so a key is never reused with the same
+nonce. The server stores the envelope verbatim and only enforces
+versioning, idempotency and conflicts; search, link parsing and
+metadata merging are disabled for the item.
+*/
+type Encryption struct {
+	KeyID        string
+	Algorithm    string
+	TitleNonce   string
+	ContentNonce string
+}
+
+func (i *Item) Encrypted() bool {
+	return i.Encryption != nil
+}
+
+func (i *Item) InPersonalWorkspace() bool {
+	return i.Personal
+}
+
+/*
+MergeMetadata applies a key-level patch on top of current metadata.
+
+- keys present in patch overwrite current keys
+- keys whose patch value is null are removed
+- keys absent from patch are kept
+
+This lets two offline devices edit different metadata keys
+without overwriting each other.
+*/
+func MergeMetadata(current, patch map[string]any) map[string]any {
+	merged := make(map[string]any, len(current)+len(patch))
+	for k, v := range current {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// SearchResult is one full-text match with its rank and highlighted snippets.
+type SearchResult struct {
+	Item           *Item
+	Rank           float64
+	TitleSnippet   string
+	ContentSnippet string
+}
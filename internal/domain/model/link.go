@@ -0,0 +1,17 @@
+package domain
+
+// ItemLink is a [[wiki link]] found in an item's content.
+type ItemLink struct {
+	SourceID  string
+	TargetRef string  // text inside [[ ]], or the target item ID
+	TargetID  *string // resolved target, nil when nothing matches
+	// Dangling is true when the target is missing or soft-deleted.
+	// Such links are kept so they heal when the target (re)appears.
+	Dangling bool
+}
+
+// LinkGraph is every live item of a user and the links between them.
+type LinkGraph struct {
+	Nodes []*Item
+	Edges []ItemLink
+}
@@ -0,0 +1,32 @@
+package domain
+
+import "time"
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+/*
+Share grants a collaborator access to another user's item.
+
+Owners and editors may update the item, viewers may only read it;
+only the owner may delete or share it. Revoking keeps the row as a
+tombstone (Revoked = true) so the collaborator's devices learn that
+the item is gone through /changes.
+*/
+type Share struct {
+	ItemID    string
+	OwnerID   string
+	UserID    string
+	Role      string
+	Version   int
+	Revoked   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func ValidShareRole(role string) bool {
+	return role == RoleEditor || role == RoleViewer
+}
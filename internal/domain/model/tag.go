@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+// Tag is synced through the global version like items,
+// so renames and deletes reach every device via /changes.
+type Tag struct {
+	ID        string
+	UserID    string
+	Name      string
+	Version   int
+	Deleted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ItemTag is a versioned assignment of a tag to an item.
+// Unassigning keeps the row as a tombstone (Deleted = true).
+type ItemTag struct {
+	ItemID    string
+	TagID     string
+	UserID    string
+	Version   int
+	Deleted   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
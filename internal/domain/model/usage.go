@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+/*
+Usage is what a user stores, kept up to date by the mutations that
+change it: live items and their title + content bytes, and the bytes
+of the user's blobs (each blob counts once, however many attachments
+point at it).
+*/
+type Usage struct {
+	UserID          string
+	ItemCount       int64
+	ContentBytes    int64
+	AttachmentBytes int64
+	UpdatedAt       time.Time
+}
+
+// Quota caps a user's Usage; a zero limit is unlimited.
+type Quota struct {
+	Items           int64
+	ContentBytes    int64
+	AttachmentBytes int64
+}
+
+// Quota resources named in QuotaExceededError.
+const (
+	QuotaItems           = "items"
+	QuotaContentBytes    = "content_bytes"
+	QuotaAttachmentBytes = "attachment_bytes"
+)
+
+/*
+Exceeded returns the first resource of u that is over its limit. Only
+resources that grew (per delta) are checked, so a user already over a
+lowered quota can still delete and shrink.
+*/
+func (q Quota) Exceeded(u *Usage, delta Usage) (string, int64, bool) {
+	switch {
+	case delta.ItemCount > 0 && q.Items > 0 && u.ItemCount > q.Items:
+		return QuotaItems, q.Items, true
+	case delta.ContentBytes > 0 && q.ContentBytes > 0 && u.ContentBytes > q.ContentBytes:
+		return QuotaContentBytes, q.ContentBytes, true
+	case delta.AttachmentBytes > 0 && q.AttachmentBytes > 0 && u.AttachmentBytes > q.AttachmentBytes:
+		return QuotaAttachmentBytes, q.AttachmentBytes, true
+	}
+	return "", 0, false
+}
+
+/*
+========================
+
+	Quota Exceeded
+
+========================
+
+The mutation would take the owner past a storage quota. Not retryable:
+replaying it fails the same way until something is deleted.
+*/
+type QuotaExceededError struct {
+	Resource string
+	Limit    int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return "quota exceeded: " + e.Resource + " limit is " + strconv.FormatInt(e.Limit, 10)
+}
+
+func (e *QuotaExceededError) IsRetryable() bool {
+	return false
+}
+
+func (e *QuotaExceededError) IsConflict() bool {
+	return false
+}
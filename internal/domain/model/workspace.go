@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+/*
+Workspace is a vault owned by a group of members.
+
+Every user also has a personal workspace whose ID equals their user ID;
+it has no stored row and its items keep using the global version.
+Items of a team workspace are versioned by the workspace's own counter,
+while the workspace and its memberships are versioned globally so they
+reach every member's devices through the regular /changes feed.
+*/
+type Workspace struct {
+	ID        string
+	OwnerID   string
+	Name      string
+	Personal  bool
+	Version   int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// WorkspaceMember is a versioned membership; removing a member keeps
+// the row as a tombstone (Removed = true).
+type WorkspaceMember struct {
+	WorkspaceID string
+	UserID      string
+	Role        string
+	Version     int
+	Removed     bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PersonalWorkspace is the implicit workspace of a single user.
+func PersonalWorkspace(userID string) *Workspace {
+	return &Workspace{ID: userID, OwnerID: userID, Name: "Personal", Personal: true}
+}
+
+func ValidWorkspaceRole(role string) bool {
+	return role == RoleOwner || role == RoleEditor || role == RoleViewer
+}
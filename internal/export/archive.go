@@ -0,0 +1,337 @@
+package export
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/repository"
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// FormatVersion is bumped whenever the archive layout changes.
+const FormatVersion = 1
+
+// Source is the repository layer the archive is read from.
+type Source struct {
+	Items       repository.ItemRepository
+	Collections repository.CollectionRepository
+	Tags        repository.TagRepository
+	Attachments repository.AttachmentRepository
+	Devices     repository.DeviceRepository
+	Mutations   repository.MutationLogRepository
+}
+
+type manifest struct {
+	FormatVersion int            `json:"format_version"`
+	UserID        string         `json:"user_id"`
+	GeneratedAt   string         `json:"generated_at"`
+	Counts        map[string]int `json:"counts"`
+}
+
+type mutationRecord struct {
+	MutationID   string `json:"mutation_id"`
+	UserID       string `json:"user_id,omitempty"`
+	EntityType   string `json:"entity_type"`
+	EntityID     string `json:"entity_id"`
+	MutationType string `json:"mutation_type"`
+	Version      int    `json:"version"`
+	AppliedAt    string `json:"applied_at"`
+}
+
+type encryptionRecord struct {
+	KeyID        string `json:"key_id"`
+	Algorithm    string `json:"algorithm"`
+	TitleNonce   string `json:"title_nonce"`
+	ContentNonce string `json:"content_nonce"`
+}
+
+type itemRecord struct {
+	ID           string            `json:"id"`
+	WorkspaceID  string            `json:"workspace_id"`
+	Type         string            `json:"type"`
+	Title        string            `json:"title"`
+	Content      string            `json:"content"`
+	Metadata     map[string]any    `json:"metadata"`
+	CollectionID *string           `json:"collection_id"`
+	Position     string            `json:"position"`
+	Encryption   *encryptionRecord `json:"encryption"`
+	Version      int               `json:"version"`
+	Deleted      bool              `json:"deleted"`
+	Purged       bool              `json:"purged"`
+	CreatedAt    string            `json:"created_at"`
+	UpdatedAt    string            `json:"updated_at"`
+	// History is every mutation applied to the item, oldest first.
+	History []mutationRecord `json:"history"`
+}
+
+type collectionRecord struct {
+	ID        string  `json:"id"`
+	ParentID  *string `json:"parent_id"`
+	Name      string  `json:"name"`
+	Version   int     `json:"version"`
+	Deleted   bool    `json:"deleted"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+type tagRecord struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Version   int    `json:"version"`
+	Deleted   bool   `json:"deleted"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type itemTagRecord struct {
+	ItemID    string `json:"item_id"`
+	TagID     string `json:"tag_id"`
+	Version   int    `json:"version"`
+	Deleted   bool   `json:"deleted"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type attachmentRecord struct {
+	ID          string `json:"id"`
+	ItemID      string `json:"item_id"`
+	SHA256      string `json:"sha256"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Version     int    `json:"version"`
+	Deleted     bool   `json:"deleted"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type deviceRecord struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	CreatedAt  string  `json:"created_at"`
+	LastSeenAt string  `json:"last_seen_at"`
+	RevokedAt  *string `json:"revoked_at"`
+}
+
+/*
+Write builds the zip archive of everything userID stores:
+
+	manifest.json      format version, generation time, record counts
+	items.json         items they created, tombstones included, each with its mutation history
+	collections.json
+	tags.json
+	item_tags.json
+	attachments.json   metadata only; file bytes are downloadable from /blobs
+	devices.json
+	mutation_log.json  every mutation they applied
+
+Encrypted items are exported as stored (ciphertext and envelope).
+*/
+func Write(ctx context.Context, out io.Writer, src Source, userID string) error {
+	items, err := src.Items.ListByCreator(ctx, userID)
+	if err != nil {
+		return err
+	}
+	itemIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		itemIDs = append(itemIDs, item.ID)
+	}
+	history, err := src.Mutations.ListByEntities(ctx, "item", itemIDs)
+	if err != nil {
+		return err
+	}
+	collections, _, err := src.Collections.GetChanges(ctx, userID, 0)
+	if err != nil {
+		return err
+	}
+	tags, itemTags, _, err := src.Tags.GetChanges(ctx, userID, 0)
+	if err != nil {
+		return err
+	}
+	attachments, _, err := src.Attachments.GetChanges(ctx, userID, 0)
+	if err != nil {
+		return err
+	}
+	devices, err := src.Devices.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	mutations, err := src.Mutations.ListByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	historyByItem := map[string][]mutationRecord{}
+	for _, m := range history {
+		historyByItem[m.EntityID] = append(historyByItem[m.EntityID], toMutationRecord(m))
+	}
+
+	files := []struct {
+		name  string
+		value any
+	}{
+		{"manifest.json", manifest{
+			FormatVersion: FormatVersion,
+			UserID:        userID,
+			GeneratedAt:   formatTime(time.Now()),
+			Counts: map[string]int{
+				"items":        len(items),
+				"collections":  len(collections),
+				"tags":         len(tags),
+				"item_tags":    len(itemTags),
+				"attachments":  len(attachments),
+				"devices":      len(devices),
+				"mutation_log": len(mutations),
+			},
+		}},
+		{"items.json", mapSlice(items, func(i *domain.Item) itemRecord {
+			return toItemRecord(i, historyByItem[i.ID])
+		})},
+		{"collections.json", mapSlice(collections, toCollectionRecord)},
+		{"tags.json", mapSlice(tags, toTagRecord)},
+		{"item_tags.json", mapSlice(itemTags, toItemTagRecord)},
+		{"attachments.json", mapSlice(attachments, toAttachmentRecord)},
+		{"devices.json", mapSlice(devices, toDeviceRecord)},
+		{"mutation_log.json", mapSlice(mutations, toMutationRecord)},
+	}
+
+	zw := zip.NewWriter(out)
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(f.value); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func mapSlice[T any, R any](in []T, fn func(T) R) []R {
+	out := make([]R, 0, len(in))
+	for _, v := range in {
+		out = append(out, fn(v))
+	}
+	return out
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func toItemRecord(i *domain.Item, history []mutationRecord) itemRecord {
+	rec := itemRecord{
+		ID:           i.ID,
+		WorkspaceID:  i.WorkspaceID,
+		Type:         i.Type,
+		Title:        i.Title,
+		Content:      i.Content,
+		Metadata:     i.Metadata,
+		CollectionID: i.CollectionID,
+		Position:     i.Position,
+		Version:      i.Version,
+		Deleted:      i.Deleted,
+		Purged:       i.Purged,
+		CreatedAt:    formatTime(i.CreatedAt),
+		UpdatedAt:    formatTime(i.UpdatedAt),
+		History:      history,
+	}
+	if rec.Metadata == nil {
+		rec.Metadata = map[string]any{}
+	}
+	if rec.History == nil {
+		rec.History = []mutationRecord{}
+	}
+	if e := i.Encryption; e != nil {
+		rec.Encryption = &encryptionRecord{
+			KeyID:        e.KeyID,
+			Algorithm:    e.Algorithm,
+			TitleNonce:   e.TitleNonce,
+			ContentNonce: e.ContentNonce,
+		}
+	}
+	return rec
+}
+
+func toCollectionRecord(c *domain.Collection) collectionRecord {
+	return collectionRecord{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Name:      c.Name,
+		Version:   c.Version,
+		Deleted:   c.Deleted,
+		CreatedAt: formatTime(c.CreatedAt),
+		UpdatedAt: formatTime(c.UpdatedAt),
+	}
+}
+
+func toTagRecord(t *domain.Tag) tagRecord {
+	return tagRecord{
+		ID:        t.ID,
+		Name:      t.Name,
+		Version:   t.Version,
+		Deleted:   t.Deleted,
+		CreatedAt: formatTime(t.CreatedAt),
+		UpdatedAt: formatTime(t.UpdatedAt),
+	}
+}
+
+func toItemTagRecord(t *domain.ItemTag) itemTagRecord {
+	return itemTagRecord{
+		ItemID:    t.ItemID,
+		TagID:     t.TagID,
+		Version:   t.Version,
+		Deleted:   t.Deleted,
+		CreatedAt: formatTime(t.CreatedAt),
+		UpdatedAt: formatTime(t.UpdatedAt),
+	}
+}
+
+func toAttachmentRecord(a *domain.Attachment) attachmentRecord {
+	return attachmentRecord{
+		ID:          a.ID,
+		ItemID:      a.ItemID,
+		SHA256:      a.SHA256,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Version:     a.Version,
+		Deleted:     a.Deleted,
+		CreatedAt:   formatTime(a.CreatedAt),
+		UpdatedAt:   formatTime(a.UpdatedAt),
+	}
+}
+
+func toDeviceRecord(d *domain.Device) deviceRecord {
+	rec := deviceRecord{
+		ID:         d.ID,
+		Name:       d.Name,
+		CreatedAt:  formatTime(d.CreatedAt),
+		LastSeenAt: formatTime(d.LastSeenAt),
+	}
+	if d.RevokedAt != nil {
+		revokedAt := formatTime(*d.RevokedAt)
+		rec.RevokedAt = &revokedAt
+	}
+	return rec
+}
+
+func toMutationRecord(m *domain.MutationLogEntry) mutationRecord {
+	return mutationRecord{
+		MutationID:   m.MutationID,
+		UserID:       m.UserID,
+		EntityType:   m.EntityType,
+		EntityID:     m.EntityID,
+		MutationType: m.MutationType,
+		Version:      m.Version,
+		AppliedAt:    formatTime(m.AppliedAt),
+	}
+}
@@ -0,0 +1,183 @@
+package export
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+/*
+Worker builds requested exports one at a time and removes archives
+once they expire.
+
+An archive is written to a temporary file and renamed into place
+before its job is marked complete, so a download never sees a partial
+archive; a crash mid-build leaves the job running until StaleAfter,
+when it is picked up again.
+*/
+type Worker struct {
+	jobs   repository.ExportJobRepository
+	source Source
+	dir    string
+	wake   chan struct{}
+
+	// TTL is how long a finished archive can be downloaded.
+	TTL time.Duration
+	// StaleAfter is when a running job is assumed abandoned.
+	StaleAfter time.Duration
+}
+
+func NewWorker(jobs repository.ExportJobRepository, source Source, dir string) (*Worker, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &Worker{
+		jobs:       jobs,
+		source:     source,
+		dir:        dir,
+		wake:       make(chan struct{}, 1),
+		TTL:        24 * time.Hour,
+		StaleAfter: time.Hour,
+	}, nil
+}
+
+// Kick wakes the worker after a job was requested.
+func (w *Worker) Kick() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// path is the archive of job id; ids are UUIDs generated by the server.
+func (w *Worker) path(id string) (string, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return "", errors.New("export: invalid job id")
+	}
+	return filepath.Join(w.dir, id+".zip"), nil
+}
+
+// Open returns the archive of a complete, unexpired job.
+func (w *Worker) Open(job *domain.ExportJob) (*os.File, error) {
+	if job.Status != domain.ExportComplete || (job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt)) {
+		return nil, domain.ErrNotFound
+	}
+	path, err := w.path(job.ID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, domain.ErrNotFound
+	}
+	return f, err
+}
+
+// Run processes jobs every interval, or sooner when kicked, until ctx is cancelled.
+func (w *Worker) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		w.drain(ctx)
+		w.expire(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+func (w *Worker) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.jobs.Claim(ctx, w.StaleAfter)
+		if err == domain.ErrNotFound {
+			return
+		}
+		if err != nil {
+			log.Printf("export: claim failed: %v", err)
+			return
+		}
+
+		size, err := w.build(ctx, job)
+		if err != nil {
+			log.Printf("export: job %s failed: %v", job.ID, err)
+			if err := w.jobs.Fail(ctx, job.ID, "export failed"); err != nil {
+				log.Printf("export: mark job %s failed: %v", job.ID, err)
+			}
+			continue
+		}
+
+		err = w.jobs.Complete(ctx, job.ID, size, time.Now().Add(w.TTL))
+		if err == domain.ErrNotFound {
+			w.Remove(job.ID)
+			continue
+		}
+		if err != nil {
+			log.Printf("export: complete job %s: %v", job.ID, err)
+			continue
+		}
+		log.Printf("export: job %s complete (%d bytes)", job.ID, size)
+	}
+}
+
+func (w *Worker) build(ctx context.Context, job *domain.ExportJob) (int64, error) {
+	path, err := w.path(job.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(w.dir, job.ID+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := Write(ctx, tmp, w.source, job.UserID); err != nil {
+		return 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		return 0, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return 0, err
+	}
+	return info.Size(), os.Rename(tmp.Name(), path)
+}
+
+func (w *Worker) expire(ctx context.Context) {
+	ids, err := w.jobs.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("export: expire failed: %v", err)
+		return
+	}
+	for _, id := range ids {
+		w.Remove(id)
+	}
+}
+
+// Remove deletes the archive of job id, if any.
+func (w *Worker) Remove(id string) {
+	path, err := w.path(id)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("export: remove %s: %v", id, err)
+	}
+}
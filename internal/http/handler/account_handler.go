@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"Offline-First/internal/blob"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/export"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AccountHandler struct {
+	usage    repository.UsageRepository
+	quota    domain.Quota
+	exports  repository.ExportJobRepository
+	worker   *export.Worker
+	erasures repository.ErasureRepository
+	store    blob.Store
+	staging  *blob.Staging
+}
+
+func NewAccountHandler(
+	usage repository.UsageRepository,
+	quota domain.Quota,
+	exports repository.ExportJobRepository,
+	worker *export.Worker,
+	erasures repository.ErasureRepository,
+	store blob.Store,
+	staging *blob.Staging,
+) *AccountHandler {
+	return &AccountHandler{
+		usage:    usage,
+		quota:    quota,
+		exports:  exports,
+		worker:   worker,
+		erasures: erasures,
+		store:    store,
+		staging:  staging,
+	}
+}
+
+// UsageEntry is one quota resource; Limit is null when unlimited.
+type UsageEntry struct {
+	Used  int64  `json:"used"`
+	Limit *int64 `json:"limit"`
+}
+
+type UsageResponse struct {
+	Items           UsageEntry `json:"items"`
+	ContentBytes    UsageEntry `json:"content_bytes"`
+	AttachmentBytes UsageEntry `json:"attachment_bytes"`
+	UpdatedAt       *string    `json:"updated_at"`
+}
+
+// Usage handles GET /account/usage.
+func (h *AccountHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	usage, err := h.usage.Get(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toUsageResponse(usage, h.quota))
+}
+
+type ExportJobResponse struct {
+	ID          string  `json:"id"`
+	Status      string  `json:"status"`
+	Size        int64   `json:"size,omitempty"`
+	CreatedAt   string  `json:"created_at"`
+	CompletedAt *string `json:"completed_at"`
+	ExpiresAt   *string `json:"expires_at"`
+	// DownloadURL is set once the archive is ready.
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+/*
+RequestExport handles POST /account/export.
+
+The archive is built in the background; the response (202) points at
+the job to poll. While an export is pending or running, requesting
+another returns that one.
+*/
+func (h *AccountHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	job, err := h.exports.Create(r.Context(), &domain.ExportJob{ID: uuid.NewString(), UserID: userID})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	h.worker.Kick()
+
+	middleware.LogWithContext(r.Context(), "export requested", "export_id", job.ID, "status", job.Status)
+
+	w.Header().Set("Location", "/account/export/"+job.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(toExportJobResponse(job))
+}
+
+// ExportStatus handles GET /account/export/{id}.
+func (h *AccountHandler) ExportStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.exportJob(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, toExportJobResponse(job))
+}
+
+// DownloadExport handles GET /account/export/{id}/download.
+func (h *AccountHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.exportJob(w, r)
+	if !ok {
+		return
+	}
+
+	archive, err := h.worker.Open(job)
+	if err == domain.ErrNotFound {
+		writeProblem(w, r, problem.NotFound("export is not ready or has expired"))
+		return
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer archive.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="export-`+job.ID+`.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeContent(w, r, "", *job.CompletedAt, archive)
+}
+
+type EraseAccountRequest struct {
+	// Confirm repeats the caller's user ID, so a stray request cannot
+	// erase an account.
+	Confirm string `json:"confirm"`
+}
+
+// ErasureResponse is the proof of erasure, as kept by the server.
+type ErasureResponse struct {
+	SubjectHash string           `json:"subject_hash"`
+	ErasedAt    string           `json:"erased_at"`
+	DeletedRows map[string]int64 `json:"deleted_rows"`
+}
+
+/*
+Erase handles DELETE /account with {"confirm": "<user id>"}.
+
+Every row of the caller is deleted in one transaction, then the files
+those rows pointed at (blobs, unfinished uploads, export archives).
+Afterwards the caller's devices get 410 account_deleted. API keys
+cannot erase an account.
+*/
+func (h *AccountHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+	if principal.APIKeyID != "" {
+		writeError(w, r, domain.ErrForbidden)
+		return
+	}
+
+	var req EraseAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+	if !strings.EqualFold(strings.TrimSpace(req.Confirm), principal.UserID) {
+		writeError(w, r, domain.NewValidationError(domain.FieldError{Field: "confirm", Message: "must repeat your user ID"}))
+		return
+	}
+
+	erasure, err := h.erasures.Erase(r.Context(), principal.UserID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the rows are gone, so nothing would collect these files later
+	ctx := context.WithoutCancel(r.Context())
+	for _, b := range erasure.Blobs {
+		if err := h.store.Delete(ctx, blob.Key(b.UserID, b.SHA256)); err != nil {
+			middleware.LogWithContext(ctx, "erasure: blob not removed", "sha256", b.SHA256, "error", err)
+		}
+	}
+	for _, id := range erasure.UploadIDs {
+		if err := h.staging.Remove(id); err != nil {
+			middleware.LogWithContext(ctx, "erasure: upload not removed", "upload_id", id, "error", err)
+		}
+	}
+	for _, id := range erasure.ExportIDs {
+		h.worker.Remove(id)
+	}
+
+	writeJSON(w, ErasureResponse{
+		SubjectHash: erasure.SubjectHash,
+		ErasedAt:    erasure.ErasedAt.Format(time.RFC3339),
+		DeletedRows: erasure.DeletedRows,
+	})
+}
+
+func (h *AccountHandler) exportJob(w http.ResponseWriter, r *http.Request) (*domain.ExportJob, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return nil, false
+	}
+	id := r.PathValue("id")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	job, err := h.exports.Get(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return nil, false
+	}
+	return job, true
+}
+
+func toExportJobResponse(j *domain.ExportJob) ExportJobResponse {
+	resp := ExportJobResponse{
+		ID:        j.ID,
+		Status:    j.Status,
+		Size:      j.Size,
+		CreatedAt: j.CreatedAt.Format(time.RFC3339),
+	}
+	if j.CompletedAt != nil {
+		completedAt := j.CompletedAt.Format(time.RFC3339)
+		resp.CompletedAt = &completedAt
+	}
+	if j.ExpiresAt != nil {
+		expiresAt := j.ExpiresAt.Format(time.RFC3339)
+		resp.ExpiresAt = &expiresAt
+	}
+	if j.Status == domain.ExportComplete {
+		resp.DownloadURL = "/account/export/" + j.ID + "/download"
+	}
+	return resp
+}
+
+func toUsageResponse(u *domain.Usage, q domain.Quota) UsageResponse {
+	entry := func(used, limit int64) UsageEntry {
+		e := UsageEntry{Used: used}
+		if limit > 0 {
+			e.Limit = &limit
+		}
+		return e
+	}
+
+	resp := UsageResponse{
+		Items:           entry(u.ItemCount, q.Items),
+		ContentBytes:    entry(u.ContentBytes, q.ContentBytes),
+		AttachmentBytes: entry(u.AttachmentBytes, q.AttachmentBytes),
+	}
+	if !u.UpdatedAt.IsZero() {
+		updatedAt := u.UpdatedAt.Format(time.RFC3339)
+		resp.UpdatedAt = &updatedAt
+	}
+	return resp
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"Offline-First/internal/auth"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyHandler(repo repository.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{repo: repo}
+}
+
+type CreateAPIKeyRequest struct {
+	Name string `json:"name"`
+	// empty for an unrestricted key
+	Scopes []string `json:"scopes"`
+}
+
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt *string  `json:"last_used_at"`
+	RevokedAt  *string  `json:"revoked_at"`
+}
+
+type CreateAPIKeyResponse struct {
+	APIKey APIKeyResponse `json:"api_key"`
+	// Key is only returned once, at creation.
+	Key string `json:"key"`
+}
+
+// Create handles POST /api-keys.
+func (h *APIKeyHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.keyManager(w, r)
+	if !ok {
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	key := &domain.APIKey{ID: uuid.NewString(), UserID: userID, Name: strings.TrimSpace(req.Name)}
+
+	verr := domain.NewValidationError()
+	if key.Name == "" || len(key.Name) > 128 {
+		verr.Add("name", "must be 1-128 bytes")
+	}
+	for _, scope := range req.Scopes {
+		if !slices.Contains(auth.APIKeyScopes, scope) {
+			verr.Add("scopes", "must be items:read, items:write or changes:read")
+			break
+		}
+		if !slices.Contains(key.Scopes, scope) {
+			key.Scopes = append(key.Scopes, scope)
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	plaintext, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	key.Prefix, key.Hash = prefix, hash
+
+	created, err := h.repo.Create(r.Context(), key)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, CreateAPIKeyResponse{APIKey: toAPIKeyResponse(created), Key: plaintext})
+}
+
+// List handles GET /api-keys.
+func (h *APIKeyHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.keyManager(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := h.repo.ListByUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]APIKeyResponse, 0, len(keys))
+	for _, k := range keys {
+		resp = append(resp, toAPIKeyResponse(k))
+	}
+	writeJSON(w, resp)
+}
+
+// Revoke handles DELETE /api-keys/{id}.
+func (h *APIKeyHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.keyManager(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api-keys/")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	revoked, err := h.repo.Revoke(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toAPIKeyResponse(revoked))
+}
+
+// keyManager returns the caller's user ID; API keys cannot manage API keys.
+func (h *APIKeyHandler) keyManager(w http.ResponseWriter, r *http.Request) (string, bool) {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return "", false
+	}
+	if principal.APIKeyID != "" {
+		writeError(w, r, domain.ErrForbidden)
+		return "", false
+	}
+	return principal.UserID, true
+}
+
+func toAPIKeyResponse(k *domain.APIKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Format(time.RFC3339),
+	}
+	if resp.Scopes == nil {
+		resp.Scopes = []string{}
+	}
+	if k.LastUsedAt != nil {
+		lastUsedAt := k.LastUsedAt.Format(time.RFC3339)
+		resp.LastUsedAt = &lastUsedAt
+	}
+	if k.RevokedAt != nil {
+		revokedAt := k.RevokedAt.Format(time.RFC3339)
+		resp.RevokedAt = &revokedAt
+	}
+	return resp
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type AttachmentHandler struct {
+	repo repository.AttachmentRepository
+}
+
+func NewAttachmentHandler(repo repository.AttachmentRepository) *AttachmentHandler {
+	return &AttachmentHandler{repo: repo}
+}
+
+type AttachmentRequest struct {
+	ID       string `json:"id"`
+	SHA256   string `json:"sha256"`
+	Filename string `json:"filename"`
+}
+
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	ItemID      string `json:"item_id"`
+	SHA256      string `json:"sha256"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Version     int    `json:"version"`
+	Deleted     bool   `json:"deleted"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Create handles POST /items/{id}/attachments for an already uploaded blob.
+func (h *AttachmentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req AttachmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	a := &domain.Attachment{
+		ID:       req.ID,
+		UserID:   userID,
+		ItemID:   r.PathValue("id"),
+		SHA256:   strings.ToLower(req.SHA256),
+		Filename: strings.TrimSpace(req.Filename),
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", a.ID, "item_id", a.ItemID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if !validSHA256(a.SHA256) {
+		verr.Add("sha256", "must be 64 hex characters")
+	}
+	if a.Filename == "" || len(a.Filename) > 255 {
+		verr.Add("filename", "must be 1-255 bytes")
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling attachment create request", "item_id", a.ItemID, "attachment_id", a.ID)
+
+	created, err := h.repo.Create(r.Context(), a, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toAttachmentResponse(created))
+}
+
+// List handles GET /items/{id}/attachments.
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	itemID := r.PathValue("id")
+	if err := requireUUIDs("item_id", itemID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	attachments, err := h.repo.ListByItem(r.Context(), userID, itemID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]AttachmentResponse, 0, len(attachments))
+	for _, a := range attachments {
+		resp = append(resp, toAttachmentResponse(a))
+	}
+	writeJSON(w, resp)
+}
+
+// Delete handles DELETE /items/{id}/attachments/{attachment_id}?version=.
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, id := r.PathValue("id"), r.PathValue("sub_id")
+	if err := requireUUIDs("item_id", itemID, "attachment_id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling attachment delete request", "attachment_id", id, "base_version", version)
+
+	deleted, err := h.repo.SoftDelete(r.Context(), id, itemID, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toAttachmentResponse(deleted))
+}
+
+func toAttachmentResponse(a *domain.Attachment) AttachmentResponse {
+	return AttachmentResponse{
+		ID:          a.ID,
+		ItemID:      a.ItemID,
+		SHA256:      a.SHA256,
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		Version:     a.Version,
+		Deleted:     a.Deleted,
+		UpdatedAt:   a.UpdatedAt.Format(time.RFC3339),
+	}
+}
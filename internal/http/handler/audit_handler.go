@@ -0,0 +1,210 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditHandler(repo repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+type AuditEventResponse struct {
+	ID         int64          `json:"id"`
+	OccurredAt string         `json:"occurred_at"`
+	Category   string         `json:"category"`
+	Action     string         `json:"action"`
+	Outcome    string         `json:"outcome"`
+	UserID     string         `json:"user_id,omitempty"`
+	DeviceID   string         `json:"device_id,omitempty"`
+	APIKeyID   string         `json:"api_key_id,omitempty"`
+	RemoteAddr string         `json:"remote_addr,omitempty"`
+	EntityType string         `json:"entity_type,omitempty"`
+	EntityID   string         `json:"entity_id,omitempty"`
+	MutationID string         `json:"mutation_id,omitempty"`
+	Version    int            `json:"version,omitempty"`
+	Detail     map[string]any `json:"detail"`
+}
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// Query handles GET /admin/audit, newest first; the next page is in X-Next-Cursor.
+func (h *AuditHandler) Query(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !h.auditAdmin(w, r, "audit.query") {
+		return
+	}
+
+	events, err := h.repo.Query(r.Context(), q)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if len(events) == q.Limit {
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(events[len(events)-1].ID, 10))
+	}
+
+	resp := make([]AuditEventResponse, 0, len(events))
+	for _, e := range events {
+		resp = append(resp, toAuditEventResponse(e))
+	}
+	writeJSON(w, resp)
+}
+
+// Export handles GET /admin/audit/export: every matching event as NDJSON, oldest first.
+func (h *AuditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	q, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if !h.auditAdmin(w, r, "audit.export") {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+
+	enc := json.NewEncoder(w)
+	err = h.repo.Export(r.Context(), q, func(e *domain.AuditEvent) error {
+		return enc.Encode(toAuditEventResponse(e))
+	})
+	if err != nil {
+		// headers are gone; a truncated stream is all the client can see
+		middleware.LogWithContext(r.Context(), "audit export failed", "error", err)
+	}
+}
+
+// auditAdmin records the admin's access to the log before it is served.
+func (h *AuditHandler) auditAdmin(w http.ResponseWriter, r *http.Request, action string) bool {
+	principal, ok := middleware.PrincipalFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return false
+	}
+
+	err := h.repo.Append(r.Context(), &domain.AuditEvent{
+		Category:   domain.AuditAdmin,
+		Action:     action,
+		Outcome:    domain.AuditSuccess,
+		UserID:     principal.UserID,
+		DeviceID:   principal.DeviceID,
+		APIKeyID:   principal.APIKeyID,
+		RemoteAddr: r.RemoteAddr,
+		Detail:     map[string]any{"query": r.URL.RawQuery},
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return false
+	}
+	return true
+}
+
+/*
+parseAuditQuery reads the filters shared by Query and Export:
+
+	?user_id=<uuid>
+	?category=auth|mutation|permission|credential|admin|account
+	?entity_id=<id>
+	?since=2024-01-01T00:00:00Z   (inclusive)
+	?until=2024-02-01T00:00:00Z   (exclusive)
+	?limit=1..1000                (default 100, Query only)
+	?cursor=<X-Next-Cursor of the previous page>
+*/
+func parseAuditQuery(q url.Values) (repository.AuditQuery, error) {
+	verr := domain.NewValidationError()
+
+	query := repository.AuditQuery{
+		UserID:   q.Get("user_id"),
+		Category: q.Get("category"),
+		EntityID: q.Get("entity_id"),
+		Limit:    defaultAuditLimit,
+	}
+
+	if query.UserID != "" {
+		if err := requireUUIDs("user_id", query.UserID); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	}
+
+	switch query.Category {
+	case "", domain.AuditAuth, domain.AuditMutation, domain.AuditPermission, domain.AuditCredential, domain.AuditAdmin, domain.AuditAccount:
+	default:
+		verr.Add("category", "must be one of auth, mutation, permission, credential, admin, account")
+	}
+
+	for _, bound := range []struct {
+		name string
+		dest *time.Time
+	}{{"since", &query.Since}, {"until", &query.Until}} {
+		if v := q.Get(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				verr.Add(bound.name, "must be an RFC 3339 timestamp")
+			}
+			*bound.dest = t
+		}
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			verr.Add("limit", "must be between 1 and 1000")
+		} else {
+			query.Limit = limit
+		}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || id < 1 {
+			verr.Add("cursor", "is invalid")
+		}
+		query.BeforeID = id
+	}
+
+	return query, verr.OrNil()
+}
+
+func toAuditEventResponse(e *domain.AuditEvent) AuditEventResponse {
+	detail := e.Detail
+	if detail == nil {
+		detail = map[string]any{}
+	}
+
+	return AuditEventResponse{
+		ID:         e.ID,
+		OccurredAt: e.OccurredAt.Format(time.RFC3339Nano),
+		Category:   e.Category,
+		Action:     e.Action,
+		Outcome:    e.Outcome,
+		UserID:     e.UserID,
+		DeviceID:   e.DeviceID,
+		APIKeyID:   e.APIKeyID,
+		RemoteAddr: e.RemoteAddr,
+		EntityType: e.EntityType,
+		EntityID:   e.EntityID,
+		MutationID: e.MutationID,
+		Version:    e.Version,
+		Detail:     detail,
+	}
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CollectionHandler struct {
+	repo repository.CollectionRepository
+}
+
+func NewCollectionHandler(repo repository.CollectionRepository) *CollectionHandler {
+	return &CollectionHandler{repo: repo}
+}
+
+type CollectionRequest struct {
+	ID string `json:"id"`
+	// raw so Update can tell an omitted parent_id from null; see optionalID
+	ParentID json.RawMessage `json:"parent_id"`
+	Name     string          `json:"name"`
+	Version  int             `json:"version"`
+}
+
+type CollectionResponse struct {
+	ID        string  `json:"id"`
+	UserID    string  `json:"user_id"`
+	ParentID  *string `json:"parent_id"`
+	Name      string  `json:"name"`
+	Version   int     `json:"version"`
+	Deleted   bool    `json:"deleted"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+func (h *CollectionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	c, err := toCollection(req, req.ID, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if c.ParentID == nil || *c.ParentID == "" {
+		c.ParentID = nil
+	}
+	if err := validateCollection(c); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling collection create request", "collection_id", c.ID)
+
+	created, err := h.repo.Create(r.Context(), c, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCollectionResponse(created))
+}
+
+func (h *CollectionHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	collections, err := h.repo.ListByUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]CollectionResponse, 0, len(collections))
+	for _, c := range collections {
+		resp = append(resp, toCollectionResponse(c))
+	}
+
+	writeJSON(w, resp)
+}
+
+// Update renames and/or moves a collection. Sending "parent_id": null moves it to the top
+// level; omitting parent_id keeps the current parent.
+func (h *CollectionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/collections/")
+	if id == "" {
+		writeProblem(w, r, problem.BadRequest("id required"))
+		return
+	}
+
+	var req CollectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	c, err := toCollection(req, id, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	c.Version = req.Version
+	if err := validateCollection(c); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling collection update request", "collection_id", id, "base_version", req.Version)
+
+	updated, err := h.repo.Update(r.Context(), c, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCollectionResponse(updated))
+}
+
+func (h *CollectionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/collections/")
+	if id == "" {
+		writeProblem(w, r, problem.BadRequest("id required"))
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling collection delete request", "collection_id", id, "base_version", version)
+
+	deleted, err := h.repo.SoftDelete(r.Context(), id, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCollectionResponse(deleted))
+}
+
+// toCollection maps an omitted parent_id to nil and null (or "") to "",
+// the repository's "keep" and "top level" on Update.
+func toCollection(req CollectionRequest, id, userID string) (*domain.Collection, error) {
+	parentID, err := optionalID(req.ParentID, "parent_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.Collection{
+		ID:       id,
+		UserID:   userID,
+		ParentID: parentID,
+		Name:     strings.TrimSpace(req.Name),
+	}, nil
+}
+
+func validateCollection(c *domain.Collection) error {
+	verr := domain.NewValidationError()
+	if _, err := uuid.Parse(c.ID); err != nil {
+		verr.Add("id", "must be a UUID")
+	}
+	if c.ParentID != nil && *c.ParentID != "" {
+		if _, err := uuid.Parse(*c.ParentID); err != nil {
+			verr.Add("parent_id", "must be a UUID")
+		} else if *c.ParentID == c.ID {
+			verr.Add("parent_id", "cannot be the collection itself")
+		}
+	}
+	if c.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if len(c.Name) > 256 {
+		verr.Add("name", "must be at most 256 bytes")
+	}
+	return verr.OrNil()
+}
+
+func toCollectionResponse(c *domain.Collection) CollectionResponse {
+	return CollectionResponse{
+		ID:        c.ID,
+		UserID:    c.UserID,
+		ParentID:  c.ParentID,
+		Name:      c.Name,
+		Version:   c.Version,
+		Deleted:   c.Deleted,
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxCommentBytes bounds a comment body.
+const maxCommentBytes = 10000
+
+type CommentHandler struct {
+	repo repository.CommentRepository
+}
+
+func NewCommentHandler(repo repository.CommentRepository) *CommentHandler {
+	return &CommentHandler{repo: repo}
+}
+
+type CommentRequest struct {
+	ID       string  `json:"id"`
+	ParentID *string `json:"parent_id"`
+	Body     string  `json:"body"`
+	Version  int     `json:"version"`
+}
+
+type CommentResponse struct {
+	ID        string  `json:"id"`
+	ItemID    string  `json:"item_id"`
+	UserID    string  `json:"user_id"`
+	ParentID  *string `json:"parent_id"`
+	Body      string  `json:"body"`
+	Version   int     `json:"version"`
+	Deleted   bool    `json:"deleted"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+}
+
+// Create handles POST /items/{id}/comments.
+func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	comment := &domain.Comment{
+		ID:       req.ID,
+		ItemID:   r.PathValue("id"),
+		UserID:   userID,
+		ParentID: req.ParentID,
+		Body:     strings.TrimSpace(req.Body),
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", comment.ID, "item_id", comment.ItemID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if comment.ParentID != nil {
+		if err := requireUUIDs("parent_id", *comment.ParentID); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	}
+	validateCommentBody(comment.Body, verr)
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling comment create request",
+		"comment_id", comment.ID,
+		"item_id", comment.ItemID,
+	)
+
+	created, err := h.repo.Create(r.Context(), comment, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCommentResponse(created))
+}
+
+// List handles GET /items/{id}/comments.
+func (h *CommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	itemID := r.PathValue("id")
+	if err := requireUUIDs("item_id", itemID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comments, err := h.repo.ListByItem(r.Context(), userID, itemID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]CommentResponse, 0, len(comments))
+	for _, c := range comments {
+		resp = append(resp, toCommentResponse(c))
+	}
+	writeJSON(w, resp)
+}
+
+// Update handles PUT /comments/{id}.
+func (h *CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req CommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	comment := &domain.Comment{
+		ID:      strings.TrimPrefix(r.URL.Path, "/comments/"),
+		UserID:  userID,
+		Body:    strings.TrimSpace(req.Body),
+		Version: req.Version,
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", comment.ID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	validateCommentBody(comment.Body, verr)
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling comment update request",
+		"comment_id", comment.ID,
+		"base_version", comment.Version,
+	)
+
+	updated, err := h.repo.Update(r.Context(), comment, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCommentResponse(updated))
+}
+
+// Delete handles DELETE /comments/{id}?version=.
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/comments/")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling comment delete request", "comment_id", id, "base_version", version)
+
+	deleted, err := h.repo.SoftDelete(r.Context(), id, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toCommentResponse(deleted))
+}
+
+func validateCommentBody(body string, verr *domain.ValidationError) {
+	if body == "" {
+		verr.Add("body", "is required")
+	}
+	if len(body) > maxCommentBytes {
+		verr.Add("body", "must be at most 10000 bytes")
+	}
+}
+
+func toCommentResponse(c *domain.Comment) CommentResponse {
+	return CommentResponse{
+		ID:        c.ID,
+		ItemID:    c.ItemID,
+		UserID:    c.UserID,
+		ParentID:  c.ParentID,
+		Body:      c.Body,
+		Version:   c.Version,
+		Deleted:   c.Deleted,
+		CreatedAt: c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: c.UpdatedAt.Format(time.RFC3339),
+	}
+}
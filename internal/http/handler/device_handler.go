@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"Offline-First/internal/audit"
+	"Offline-First/internal/auth"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type DeviceHandler struct {
+	repo   repository.DeviceRepository
+	tokens *auth.TokenService
+	audit  *audit.Recorder
+}
+
+func NewDeviceHandler(repo repository.DeviceRepository, tokens *auth.TokenService, recorder *audit.Recorder) *DeviceHandler {
+	return &DeviceHandler{repo: repo, tokens: tokens, audit: recorder}
+}
+
+type RegisterDeviceRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type DeviceResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	CreatedAt  string  `json:"created_at"`
+	LastSeenAt string  `json:"last_seen_at"`
+	RevokedAt  *string `json:"revoked_at"`
+}
+
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RegisterDeviceResponse struct {
+	Device DeviceResponse `json:"device"`
+	TokenResponse
+}
+
+// Register handles POST /devices.
+func (h *DeviceHandler) Register(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req RegisterDeviceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	device := &domain.Device{ID: req.ID, UserID: userID, Name: strings.TrimSpace(req.Name)}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", device.ID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if device.Name == "" || len(device.Name) > 128 {
+		verr.Add("name", "must be 1-128 bytes")
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	refresh, stored, err := h.tokens.NewRefreshToken(device.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling device registration", "device_id", device.ID)
+
+	registered, err := h.repo.Register(r.Context(), device, stored)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tokens, ok := h.tokenResponse(w, r, registered, refresh)
+	if !ok {
+		return
+	}
+	writeJSON(w, RegisterDeviceResponse{Device: toDeviceResponse(registered), TokenResponse: tokens})
+}
+
+// Refresh handles POST /auth/refresh; it is reachable without an access token.
+func (h *DeviceHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	tokenID, hash, ok := auth.ParseRefreshToken(req.RefreshToken)
+	if !ok {
+		h.refreshFailed(r, "", domain.ErrUnauthorized)
+		writeError(w, r, domain.ErrUnauthorized)
+		return
+	}
+
+	refresh, next, err := h.tokens.NewRefreshToken("")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	device, err := h.repo.Rotate(r.Context(), tokenID, hash, next)
+	if err != nil {
+		h.refreshFailed(r, tokenID, err)
+		writeError(w, r, err)
+		return
+	}
+
+	tokens, ok := h.tokenResponse(w, r, device, refresh)
+	if !ok {
+		return
+	}
+	writeJSON(w, tokens)
+}
+
+// refreshFailed audits a rejected refresh; successes are audited by Rotate.
+func (h *DeviceHandler) refreshFailed(r *http.Request, tokenID string, err error) {
+	if !errors.Is(err, domain.ErrUnauthorized) && !errors.Is(err, domain.ErrDeviceRevoked) && !errors.Is(err, domain.ErrAccountDeleted) {
+		return
+	}
+	e := &domain.AuditEvent{
+		Category:   domain.AuditAuth,
+		Action:     "refresh",
+		Outcome:    domain.AuditFailure,
+		RemoteAddr: r.RemoteAddr,
+		Detail:     map[string]any{"reason": err.Error()},
+	}
+	if tokenID != "" {
+		e.Detail["token_id"] = tokenID
+	}
+	h.audit.Record(e)
+}
+
+// List handles GET /devices.
+func (h *DeviceHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	devices, err := h.repo.ListByUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]DeviceResponse, 0, len(devices))
+	for _, d := range devices {
+		resp = append(resp, toDeviceResponse(d))
+	}
+	writeJSON(w, resp)
+}
+
+// Revoke handles DELETE /devices/{id}.
+func (h *DeviceHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/devices/")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	revoked, err := h.repo.Revoke(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toDeviceResponse(revoked))
+}
+
+func (h *DeviceHandler) tokenResponse(w http.ResponseWriter, r *http.Request, d *domain.Device, refresh string) (TokenResponse, bool) {
+	access, err := h.tokens.IssueAccessToken(d.UserID, d.ID)
+	if err != nil {
+		writeError(w, r, err)
+		return TokenResponse{}, false
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	return TokenResponse{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(h.tokens.AccessTTL / time.Second),
+		RefreshToken: refresh,
+	}, true
+}
+
+func toDeviceResponse(d *domain.Device) DeviceResponse {
+	resp := DeviceResponse{
+		ID:         d.ID,
+		Name:       d.Name,
+		CreatedAt:  d.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: d.LastSeenAt.Format(time.RFC3339),
+	}
+	if d.RevokedAt != nil {
+		revokedAt := d.RevokedAt.Format(time.RFC3339)
+		resp.RevokedAt = &revokedAt
+	}
+	return resp
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"errors"
+	"net/http"
+)
+
+// writeProblem writes p and echoes the mutation ID of the request, if any.
+func writeProblem(w http.ResponseWriter, r *http.Request, p *problem.Problem) {
+	if mutationID, ok := middleware.MutationIDFromContext(r.Context()); ok {
+		p.WithMutationID(mutationID)
+	}
+	p.Write(w)
+}
+
+// writeError converts a repository error into the shared error envelope.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := problem.FromError(err)
+
+	var ce *domain.ConflictError
+	if errors.As(err, &ce) {
+		if ce.ServerItem != nil {
+			p.ServerItem = toItemResponse(ce.ServerItem)
+			w.Header().Set("ETag", itemETag(ce.ServerItem))
+		}
+		p.ServerState = toStateResponse(ce.ServerState)
+	}
+
+	var cycle *domain.CycleConflictError
+	if errors.As(err, &cycle) {
+		p.ServerState = toStateResponse(cycle.Collection)
+	}
+
+	if p.Status >= http.StatusInternalServerError {
+		middleware.LogWithContext(r.Context(), "request failed", "error", err)
+	}
+
+	writeProblem(w, r, p)
+}
+
+// toStateResponse renders the server copy of a non-item entity on conflicts.
+func toStateResponse(state any) any {
+	switch s := state.(type) {
+	case *domain.Tag:
+		return toTagResponse(s)
+	case *domain.ItemTag:
+		return toItemTagResponse(s)
+	case *domain.Collection:
+		return toCollectionResponse(s)
+	case *domain.Workspace:
+		return toWorkspaceResponse(s)
+	case *domain.WorkspaceMember:
+		return toMemberResponse(s)
+	case *domain.Share:
+		return toShareResponse(s)
+	case *domain.Attachment:
+		return toAttachmentResponse(s)
+	case *domain.Upload:
+		return toUploadResponse(s)
+	case *domain.Comment:
+		return toCommentResponse(s)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+/*
+ETags are the item's global version, quoted: "42".
+
+Because versions are never reused, the version alone identifies
+one exact state of an item.
+*/
+func itemETag(item *domain.Item) string {
+	return `"` + strconv.Itoa(item.Version) + `"`
+}
+
+// parseETagVersion accepts "42" and W/"42".
+func parseETagVersion(tag string) (int, bool) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	v, err := strconv.Atoi(tag[1 : len(tag)-1])
+	return v, err == nil
+}
+
+// etagMatches implements the If-None-Match comparison (weak, list or *).
+func etagMatches(header string, item *domain.Item) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if v, ok := parseETagVersion(tag); ok && v == item.Version {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+ifMatchVersion reads the base version from If-Match, as an alternative
+to the body / query "version" field. "*" carries no version.
+*/
+func ifMatchVersion(r *http.Request) (version int, present bool, valid bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, false, true
+	}
+	v, ok := parseETagVersion(header)
+	return v, true, ok
+}
@@ -3,64 +3,91 @@ package handler
 import (
 	domain "Offline-First/internal/domain/model"
 	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
 	"Offline-First/internal/repository"
+	"Offline-First/internal/validation"
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// ItemValidator rejects invalid payloads before they reach the repository.
+type ItemValidator interface {
+	Validate(item *domain.Item) error
+}
+
 type ItemHandler struct {
-	repo repository.ItemRepository
+	repo      repository.ItemRepository
+	validator ItemValidator
 }
 
-func NewItemHandler(repo repository.ItemRepository) *ItemHandler {
-	return &ItemHandler{repo: repo}
+func NewItemHandler(repo repository.ItemRepository, validator ItemValidator) *ItemHandler {
+	return &ItemHandler{repo: repo, validator: validator}
 }
 
 type CreateItemRequest struct {
-	ID      string `json:"id"`
-	UserID  string `json:"user_id"`
-	Type    string `json:"type"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	Version int    `json:"version"`
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// defaults to the caller's personal workspace; fixed after create
+	WorkspaceID string         `json:"workspace_id"`
+	Type        string         `json:"type"`
+	Title       string         `json:"title"`
+	Content     string         `json:"content"`
+	Metadata    map[string]any `json:"metadata"`
+	// raw so Update can tell an omitted collection_id from null; see optionalID
+	CollectionID json.RawMessage `json:"collection_id"`
+	// set for end-to-end encrypted items; title and content are then base64 ciphertext
+	Encryption *EncryptionEnvelope `json:"encryption"`
+	// fractional ordering key, create only; PUT /items/{id}/position moves an item
+	Position string `json:"position"`
+	Version  int    `json:"version"`
 }
 
-type ItemResponse struct {
-	ID        string `json:"id"`
-	UserID    string `json:"user_id"`
-	Type      string `json:"type"`
-	Title     string `json:"title"`
-	Content   string `json:"content"`
-	Version   int    `json:"version"`
-	Deleted   bool   `json:"deleted"`
-	UpdatedAt string `json:"updated_at"`
+type EncryptionEnvelope struct {
+	KeyID        string `json:"key_id"`
+	Algorithm    string `json:"algorithm"`
+	TitleNonce   string `json:"title_nonce"`
+	ContentNonce string `json:"content_nonce"`
 }
 
-type ChangeResponse struct {
-	LatestVersion int            `json:"latest_version"`
-	Items         []ItemResponse `json:"items"`
+type ItemResponse struct {
+	ID           string         `json:"id"`
+	UserID       string         `json:"user_id"`
+	WorkspaceID  string         `json:"workspace_id"`
+	Type         string         `json:"type"`
+	Title        string         `json:"title"`
+	Content      string         `json:"content"`
+	Metadata     map[string]any `json:"metadata"`
+	CollectionID *string        `json:"collection_id"`
+	// null for plaintext items
+	Encryption *EncryptionEnvelope `json:"encryption"`
+	Position   string              `json:"position"`
+	Version    int                 `json:"version"`
+	Deleted    bool                `json:"deleted"`
+	Purged     bool                `json:"purged"`
+	UpdatedAt  string              `json:"updated_at"`
 }
 
 func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorised!", http.StatusUnauthorized)
+		writeProblem(w, r, problem.Unauthorized("unauthorised!"))
 		return
 
 	}
 
 	mutationID, ok := middleware.MutationIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "missing mutation id", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("missing mutation id"))
 		return
 	}
 
 	var req CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json!", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("invalid json!"))
 		return
 	}
 
@@ -70,30 +97,42 @@ func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
 		"item_id", req.ID,
 	)
 
+	collectionID, err := optionalID(req.CollectionID, "collection_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if collectionID != nil && *collectionID == "" {
+		collectionID = nil
+	}
+
 	item := &domain.Item{
-		ID:      req.ID,
-		UserID:  userID,
-		Type:    req.Type,
-		Title:   req.Title,
-		Content: req.Content,
-		Version: 1,
-		Deleted: false,
+		ID:           req.ID,
+		UserID:       userID,
+		WorkspaceID:  req.WorkspaceID,
+		Type:         req.Type,
+		Title:        req.Title,
+		Content:      req.Content,
+		Metadata:     req.Metadata,
+		CollectionID: collectionID,
+		Encryption:   toDomainEncryption(req.Encryption),
+		Position:     req.Position,
+		Version:      1,
+		Deleted:      false,
+	}
+
+	if err := h.validator.Validate(item); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
 	created, err := h.repo.Create(r.Context(), item, mutationID)
 	if err != nil {
-		if me, ok := err.(domain.MutationError); ok {
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error":     err.Error(),
-				"retryable": me.IsRetryable(),
-			})
-			return
-		}
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", itemETag(created))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(toItemResponse(created))
 }
@@ -101,29 +140,30 @@ func (h *ItemHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *ItemHandler) List(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	opts, err := parseListOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	items, err := h.repo.ListByUser(r.Context(), userID)
+	items, nextCursor, err := h.repo.ListByUser(r.Context(), userID, opts)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	if nextCursor != "" {
+		w.Header().Set("X-Next-Cursor", nextCursor)
+	}
+
 	resp := make([]ItemResponse, 0, len(items))
 
 	for _, item := range items {
-		resp = append(resp, ItemResponse{
-			ID:        item.ID,
-			UserID:    item.UserID,
-			Type:      item.Type,
-			Title:     item.Title,
-			Content:   item.Content,
-			Version:   item.Version,
-			Deleted:   item.Deleted,
-			UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
-		})
+		resp = append(resp, toItemResponse(item))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -133,28 +173,41 @@ func (h *ItemHandler) List(w http.ResponseWriter, r *http.Request) {
 func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
 		return
 	}
 
 	mutationID, ok := middleware.MutationIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "missing mutation id", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("missing mutation id"))
 		return
 	}
 
 	id := strings.TrimPrefix(r.URL.Path, "/items/")
 	if id == "" {
-		http.Error(w, "id required!", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("id required!"))
 		return
 	}
 
 	var req CreateItemRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("invalid json"))
 		return
 	}
 
+	// If-Match may carry the base version instead of the body
+	if v, present, valid := ifMatchVersion(r); present {
+		if !valid {
+			writeProblem(w, r, problem.BadRequest("invalid If-Match"))
+			return
+		}
+		if req.Version != 0 && req.Version != v {
+			writeProblem(w, r, problem.BadRequest("If-Match and version disagree"))
+			return
+		}
+		req.Version = v
+	}
+
 	middleware.LogWithContext(
 		r.Context(),
 		"handling update request",
@@ -162,41 +215,37 @@ func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
 		"base_version", req.Version,
 	)
 
+	collectionID, err := optionalID(req.CollectionID, "collection_id")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
 	item := &domain.Item{
-		ID:      id,
-		UserID:  userID,
-		Type:    req.Type,
-		Title:   req.Title,
-		Content: req.Content,
-		Version: req.Version,
+		ID:           id,
+		UserID:       userID,
+		WorkspaceID:  req.WorkspaceID,
+		Type:         req.Type,
+		Title:        req.Title,
+		Content:      req.Content,
+		Metadata:     req.Metadata,
+		CollectionID: collectionID,
+		Encryption:   toDomainEncryption(req.Encryption),
+		Version:      req.Version,
+	}
+
+	if err := h.validator.Validate(item); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
 	updated, err := h.repo.Update(r.Context(), item, mutationID)
 	if err != nil {
-		if me, ok := err.(domain.MutationError); ok {
-			if me.IsConflict() {
-				ce := err.(*domain.ConflictError)
-
-				w.WriteHeader(http.StatusConflict)
-				json.NewEncoder(w).Encode(map[string]interface{}{
-					"error":       "version_conflict",
-					"retryable":   false,
-					"server_item": toItemResponse(ce.ServerItem),
-				})
-				return
-			}
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error":     "mutation_failed",
-				"retryable": me.IsRetryable(),
-			})
-			return
-		}
-
-		http.Error(w, "internal Error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", itemETag(updated))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(toItemResponse(updated))
 }
@@ -204,30 +253,24 @@ func (h *ItemHandler) Update(w http.ResponseWriter, r *http.Request) {
 func (h *ItemHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
 		return
 	}
 
 	mutationID, ok := middleware.MutationIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "missing mutation id", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("missing mutation id"))
+		return
 	}
 
 	id := strings.TrimPrefix(r.URL.Path, "/items/")
 	if id == "" {
-		http.Error(w, "id required", http.StatusBadRequest)
-		return
-	}
-
-	versionStr := r.URL.Query().Get("version")
-	if versionStr == "" {
-		http.Error(w, "version is required", http.StatusBadRequest)
+		writeProblem(w, r, problem.BadRequest("id required"))
 		return
 	}
 
-	version, err := strconv.Atoi(versionStr)
-	if err != nil {
-		http.Error(w, "invalid version", http.StatusBadRequest)
+	version, ok := deleteVersion(w, r)
+	if !ok {
 		return
 	}
 
@@ -240,94 +283,437 @@ func (h *ItemHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	deletedItem, err := h.repo.SoftDelete(r.Context(), id, userID, version, mutationID)
 	if err != nil {
-		if me, ok := err.(domain.MutationError); ok {
-			if me.IsConflict() {
-				ce := err.(*domain.ConflictError)
-
-				w.WriteHeader(http.StatusConflict)
-				json.NewEncoder(w).Encode(map[string]interface{}{
-					"error":       "version_conflict",
-					"retryable":   false,
-					"server_item": toItemResponse(ce.ServerItem),
-				})
-				return
-			}
-
-			w.WriteHeader(http.StatusInternalServerError)
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"error":     "mutation_failed",
-				"retryable": me.IsRetryable(),
-			})
-			return
-		}
-
-		http.Error(w, "internal error", http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", itemETag(deletedItem))
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(toItemResponse(deletedItem))
 }
 
-func (h *ItemHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.UserIDFromContext(r.Context())
+/*
+Purge handles DELETE /items/{id}/purge ("delete forever" from the
+trash). The item must already be deleted; the version comes from
+?version= or If-Match as for Delete.
+*/
+func (h *ItemHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	sinceStr := r.URL.Query().Get("since_version")
-	if sinceStr == "" {
-		http.Error(w, "since_version is required!", http.StatusBadRequest)
+	id := r.PathValue("id")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	version, ok := deleteVersion(w, r)
+	if !ok {
 		return
 	}
 
-	sinceVersion, err := strconv.Atoi(sinceStr)
+	purged, err := h.repo.Purge(r.Context(), id, userID, version, mutationID)
 	if err != nil {
-		http.Error(w, "invalid since_version!", http.StatusBadRequest)
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "item purged", "item_id", id, "version", purged.Version)
+
+	w.Header().Set("ETag", itemETag(purged))
+	writeJSON(w, toItemResponse(purged))
+}
+
+/*
+Get handles GET /items/{id}.
+
+Tombstones are returned too (deleted: true) so a device can learn
+that an item it still shows was deleted elsewhere.
+Supports If-None-Match → 304 using the version ETag.
+*/
+func (h *ItemHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	items, latestVersion, err := h.repo.GetChanges(r.Context(), userID, sinceVersion)
+	item, err := h.repo.GetByID(r.Context(), userID, id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	itemResponse := make([]ItemResponse, 0, len(items))
+	w.Header().Set("ETag", itemETag(item))
 
-	for _, item := range items {
-		itemResponse = append(itemResponse, ItemResponse{
-			ID:        item.ID,
-			UserID:    item.UserID,
-			Type:      item.Type,
-			Title:     item.Title,
-			Content:   item.Content,
-			Version:   item.Version,
-			Deleted:   item.Deleted,
-			UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
-		})
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, item) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toItemResponse(item))
+}
+
+// deleteVersion reads ?version=, falling back to If-Match.
+func deleteVersion(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v, present, valid := ifMatchVersion(r)
+	if present && !valid {
+		writeProblem(w, r, problem.BadRequest("invalid If-Match"))
+		return 0, false
+	}
+
+	if r.URL.Query().Get("version") == "" {
+		if !present {
+			writeProblem(w, r, problem.BadRequest("version or If-Match is required"))
+			return 0, false
+		}
+		return v, true
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return 0, false
+	}
+	if present && v != version {
+		writeProblem(w, r, problem.BadRequest("If-Match and version disagree"))
+		return 0, false
+	}
+	return version, true
+}
+
+type SearchResultResponse struct {
+	Item           ItemResponse `json:"item"`
+	Rank           float64      `json:"rank"`
+	TitleSnippet   string       `json:"title_snippet"`
+	ContentSnippet string       `json:"content_snippet"`
+}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+/*
+Search handles GET /items/search.
+
+	?q=            required, web-search syntax ("exact phrase", -word, or)
+	?type=         repeatable or comma-separated
+	?include_deleted=true
+	?limit=        default 20, max 100
+*/
+func (h *ItemHandler) Search(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
 	}
 
-	resp := ChangeResponse{
-		LatestVersion: latestVersion,
-		Items:         itemResponse,
+	q := r.URL.Query()
+	query := repository.ItemSearchQuery{
+		Query: strings.TrimSpace(q.Get("q")),
+		Limit: defaultSearchLimit,
+	}
+	if query.Query == "" {
+		writeProblem(w, r, problem.BadRequest("q is required"))
+		return
+	}
+
+	for _, t := range q["type"] {
+		for _, part := range strings.Split(t, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				query.Types = append(query.Types, part)
+			}
+		}
+	}
+
+	if v := q.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			writeProblem(w, r, problem.BadRequest("invalid include_deleted"))
+			return
+		}
+		query.IncludeDeleted = includeDeleted
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			writeProblem(w, r, problem.BadRequest("limit must be between 1 and 100"))
+			return
+		}
+		query.Limit = limit
+	}
+
+	results, err := h.repo.Search(r.Context(), userID, query)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]SearchResultResponse, 0, len(results))
+	for _, res := range results {
+		resp = append(resp, SearchResultResponse{
+			Item:           toItemResponse(res.Item),
+			Rank:           res.Rank,
+			TitleSnippet:   res.TitleSnippet,
+			ContentSnippet: res.ContentSnippet,
+		})
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+const (
+	defaultListLimit = 100
+	maxListLimit     = 500
+)
+
+/*
+parseListOptions reads filters, sort and paging from the query string:
+
+	?workspace_id=<uuid>
+	?collection_id=<uuid>|root
+	?type=note
+	?updated_since=2024-01-01T00:00:00Z
+	?include_deleted=true
+	?metadata_has=source_url
+	?metadata.color=red
+	?sort=updated_at|created_at|title|version|position  (default updated_at)
+	?order=asc|desc                                     (default desc)
+	?limit=1..500                                       (default 100)
+	?cursor=<X-Next-Cursor of the previous page>
+*/
+func parseListOptions(q url.Values) (repository.ItemListOptions, error) {
+	verr := domain.NewValidationError()
+
+	opts := repository.ItemListOptions{
+		WorkspaceID: q.Get("workspace_id"),
+		Type:        q.Get("type"),
+		MetadataHas: q.Get("metadata_has"),
+		SortField:   repository.SortUpdatedAt,
+		SortDesc:    true,
+		Limit:       defaultListLimit,
+		Cursor:      q.Get("cursor"),
+	}
+
+	if opts.WorkspaceID != "" {
+		if err := requireUUIDs("workspace_id", opts.WorkspaceID); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	}
+
+	if v := q.Get("collection_id"); v != "" {
+		opts.CollectionID = v
+		if v != repository.CollectionRoot {
+			if err := requireUUIDs("collection_id", v); err != nil {
+				verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+			}
+		}
+	}
+
+	if v := q.Get("updated_since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			verr.Add("updated_since", "must be an RFC 3339 timestamp")
+		} else {
+			opts.UpdatedSince = &since
+		}
+	}
+
+	if v := q.Get("include_deleted"); v != "" {
+		includeDeleted, err := strconv.ParseBool(v)
+		if err != nil {
+			verr.Add("include_deleted", "must be a boolean")
+		}
+		opts.IncludeDeleted = includeDeleted
+	}
+
+	switch v := q.Get("sort"); v {
+	case "":
+	case repository.SortUpdatedAt, repository.SortCreatedAt, repository.SortTitle, repository.SortVersion, repository.SortPosition:
+		opts.SortField = v
+	default:
+		verr.Add("sort", "must be one of updated_at, created_at, title, version, position")
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		opts.SortDesc = false
+	default:
+		verr.Add("order", "must be asc or desc")
+	}
+
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			verr.Add("limit", "must be between 1 and 500")
+		} else {
+			opts.Limit = limit
+		}
+	}
+
+	for key, values := range q {
+		if name, ok := strings.CutPrefix(key, "metadata."); ok && name != "" && len(values) > 0 {
+			if opts.MetadataEquals == nil {
+				opts.MetadataEquals = map[string]string{}
+			}
+			opts.MetadataEquals[name] = values[0]
+		}
+	}
+
+	return opts, verr.OrNil()
+}
+
 func toItemResponse(item *domain.Item) ItemResponse {
+	metadata := item.Metadata
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+
 	return ItemResponse{
-		ID:        item.ID,
-		UserID:    item.UserID,
-		Type:      item.Type,
-		Title:     item.Title,
-		Content:   item.Content,
-		Version:   item.Version,
-		Deleted:   item.Deleted,
-		UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+		ID:           item.ID,
+		UserID:       item.UserID,
+		WorkspaceID:  item.WorkspaceID,
+		Type:         item.Type,
+		Title:        item.Title,
+		Content:      item.Content,
+		Metadata:     metadata,
+		CollectionID: item.CollectionID,
+		Encryption:   toEncryptionEnvelope(item.Encryption),
+		Position:     item.Position,
+		Version:      item.Version,
+		Deleted:      item.Deleted,
+		Purged:       item.Purged,
+		UpdatedAt:    item.UpdatedAt.Format(time.RFC3339),
 	}
 
 }
+
+func toDomainEncryption(e *EncryptionEnvelope) *domain.Encryption {
+	if e == nil {
+		return nil
+	}
+	return &domain.Encryption{
+		KeyID:        e.KeyID,
+		Algorithm:    e.Algorithm,
+		TitleNonce:   e.TitleNonce,
+		ContentNonce: e.ContentNonce,
+	}
+}
+
+func toEncryptionEnvelope(e *domain.Encryption) *EncryptionEnvelope {
+	if e == nil {
+		return nil
+	}
+	return &EncryptionEnvelope{
+		KeyID:        e.KeyID,
+		Algorithm:    e.Algorithm,
+		TitleNonce:   e.TitleNonce,
+		ContentNonce: e.ContentNonce,
+	}
+}
+
+type ReorderRequest struct {
+	// exactly one of position, after_id or before_id
+	Position string `json:"position"`
+	AfterID  string `json:"after_id"`
+	BeforeID string `json:"before_id"`
+	Version  int    `json:"version"`
+}
+
+// Reorder handles PUT /items/{id}/position.
+func (h *ItemHandler) Reorder(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReorderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	if v, present, valid := ifMatchVersion(r); present {
+		if !valid {
+			writeProblem(w, r, problem.BadRequest("invalid If-Match"))
+			return
+		}
+		if req.Version != 0 && req.Version != v {
+			writeProblem(w, r, problem.BadRequest("If-Match and version disagree"))
+			return
+		}
+		req.Version = v
+	}
+
+	move := repository.ItemMove{
+		ID:       r.PathValue("id"),
+		UserID:   userID,
+		Position: req.Position,
+		AfterID:  req.AfterID,
+		BeforeID: req.BeforeID,
+		Version:  req.Version,
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", move.ID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	switch {
+	case countSet(move.Position, move.AfterID, move.BeforeID) != 1:
+		verr.Add("position", "exactly one of position, after_id or before_id is required")
+	case move.Position != "":
+		if err := validation.ValidatePosition(move.Position); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	case move.AfterID != "":
+		if err := requireUUIDs("after_id", move.AfterID); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	default:
+		if err := requireUUIDs("before_id", move.BeforeID); err != nil {
+			verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+		}
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(
+		r.Context(),
+		"handling reorder request",
+		"item_id", move.ID,
+		"base_version", move.Version,
+	)
+
+	moved, err := h.repo.Reorder(r.Context(), move, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", itemETag(moved))
+	writeJSON(w, toItemResponse(moved))
+}
+
+func countSet(values ...string) int {
+	n := 0
+	for _, v := range values {
+		if v != "" {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"net/http"
+)
+
+type LinkHandler struct {
+	repo repository.LinkRepository
+}
+
+func NewLinkHandler(repo repository.LinkRepository) *LinkHandler {
+	return &LinkHandler{repo: repo}
+}
+
+type GraphNodeResponse struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title"`
+}
+
+type GraphEdgeResponse struct {
+	SourceID  string  `json:"source_id"`
+	TargetRef string  `json:"target_ref"`
+	TargetID  *string `json:"target_id"`
+	Dangling  bool    `json:"dangling"`
+}
+
+type GraphResponse struct {
+	Nodes []GraphNodeResponse `json:"nodes"`
+	Edges []GraphEdgeResponse `json:"edges"`
+}
+
+// Backlinks handles GET /items/{id}/backlinks.
+func (h *LinkHandler) Backlinks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := r.PathValue("id")
+	if err := requireUUIDs("id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items, err := h.repo.Backlinks(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]ItemResponse, 0, len(items))
+	for _, item := range items {
+		resp = append(resp, toItemResponse(item))
+	}
+
+	writeJSON(w, resp)
+}
+
+// Graph handles GET /graph.
+func (h *LinkHandler) Graph(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	graph, err := h.repo.Graph(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toGraphResponse(graph))
+}
+
+func toGraphResponse(graph *domain.LinkGraph) GraphResponse {
+	resp := GraphResponse{
+		Nodes: make([]GraphNodeResponse, 0, len(graph.Nodes)),
+		Edges: make([]GraphEdgeResponse, 0, len(graph.Edges)),
+	}
+
+	for _, item := range graph.Nodes {
+		resp.Nodes = append(resp.Nodes, GraphNodeResponse{
+			ID:    item.ID,
+			Type:  item.Type,
+			Title: item.Title,
+		})
+	}
+
+	for _, link := range graph.Edges {
+		resp.Edges = append(resp.Edges, GraphEdgeResponse{
+			SourceID:  link.SourceID,
+			TargetRef: link.TargetRef,
+			TargetID:  link.TargetID,
+			Dangling:  link.Dangling,
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// mutationContext extracts the caller and X-MUTATION-ID, writing the error itself.
+func mutationContext(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return "", "", false
+	}
+
+	mutationID, ok := middleware.MutationIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.BadRequest("missing mutation id"))
+		return "", "", false
+	}
+
+	return userID, mutationID, true
+}
+
+func versionFromQuery(w http.ResponseWriter, r *http.Request) (int, bool) {
+	versionStr := r.URL.Query().Get("version")
+	if versionStr == "" {
+		writeProblem(w, r, problem.BadRequest("version is required"))
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(versionStr)
+	if err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid version"))
+		return 0, false
+	}
+	return version, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+/*
+optionalID decodes a nullable reference such as an item's collection_id
+or a collection's parent_id. An omitted field yields nil (keep the current
+value on update); null and "" both yield "" (top level).
+*/
+func optionalID(raw json.RawMessage, field string) (*string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var v *string
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, domain.NewValidationError(domain.FieldError{Field: field, Message: "must be a UUID or null"})
+	}
+	if v == nil {
+		v = new(string)
+	}
+	return v, nil
+}
+
+// requireUUIDs validates (field, value) pairs taken from the URL path.
+func requireUUIDs(pairs ...string) error {
+	verr := domain.NewValidationError()
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if _, err := uuid.Parse(pairs[i+1]); err != nil {
+			verr.Add(pairs[i], "must be a UUID")
+		}
+	}
+	return verr.OrNil()
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOptionalID(t *testing.T) {
+	id := "7f1c9a52-3f0e-4c7b-9d2a-1b5e8c6f4a10"
+	for _, tc := range []struct {
+		body string
+		want *string
+	}{
+		{`{}`, nil},
+		{`{"collection_id":null}`, new(string)},
+		{`{"collection_id":""}`, new(string)},
+		{`{"collection_id":"` + id + `"}`, &id},
+	} {
+		var req CreateItemRequest
+		if err := json.Unmarshal([]byte(tc.body), &req); err != nil {
+			t.Fatal(err)
+		}
+		got, err := optionalID(req.CollectionID, "collection_id")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.body, err)
+		}
+		if (got == nil) != (tc.want == nil) || (got != nil && *got != *tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.body, got, tc.want)
+		}
+	}
+
+	if _, err := optionalID(json.RawMessage(`42`), "collection_id"); err == nil {
+		t.Error("a number must be rejected")
+	}
+}
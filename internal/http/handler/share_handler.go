@@ -0,0 +1,158 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type ShareHandler struct {
+	repo repository.ShareRepository
+}
+
+func NewShareHandler(repo repository.ShareRepository) *ShareHandler {
+	return &ShareHandler{repo: repo}
+}
+
+type ShareRequest struct {
+	Role    string `json:"role"`
+	Version int    `json:"version"` // 0 when not yet shared with this user
+}
+
+type ShareResponse struct {
+	ItemID    string `json:"item_id"`
+	OwnerID   string `json:"owner_id"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	Version   int    `json:"version"`
+	Revoked   bool   `json:"revoked"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// Share handles PUT /items/{id}/shares/{user_id}.
+func (h *ShareHandler) Share(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req ShareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	share := &domain.Share{
+		ItemID:  r.PathValue("id"),
+		OwnerID: userID,
+		UserID:  r.PathValue("sub_id"),
+		Role:    req.Role,
+		Version: req.Version,
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("item_id", share.ItemID, "user_id", share.UserID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if share.UserID == userID {
+		verr.Add("user_id", "cannot share an item with its owner")
+	}
+	if !domain.ValidShareRole(share.Role) {
+		verr.Add("role", "must be editor or viewer")
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling share request",
+		"item_id", share.ItemID,
+		"collaborator_id", share.UserID,
+		"role", share.Role,
+		"base_version", share.Version,
+	)
+
+	shared, err := h.repo.Share(r.Context(), share, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toShareResponse(shared))
+}
+
+// Unshare handles DELETE /items/{id}/shares/{user_id}?version=.
+func (h *ShareHandler) Unshare(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, collaboratorID := r.PathValue("id"), r.PathValue("sub_id")
+	if err := requireUUIDs("item_id", itemID, "user_id", collaboratorID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling unshare request",
+		"item_id", itemID,
+		"collaborator_id", collaboratorID,
+		"base_version", version,
+	)
+
+	revoked, err := h.repo.Unshare(r.Context(), itemID, collaboratorID, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toShareResponse(revoked))
+}
+
+// List handles GET /items/{id}/shares.
+func (h *ShareHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	itemID := r.PathValue("id")
+	if err := requireUUIDs("item_id", itemID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	shares, err := h.repo.ListByItem(r.Context(), userID, itemID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]ShareResponse, 0, len(shares))
+	for _, s := range shares {
+		resp = append(resp, toShareResponse(s))
+	}
+	writeJSON(w, resp)
+}
+
+func toShareResponse(s *domain.Share) ShareResponse {
+	return ShareResponse{
+		ItemID:    s.ItemID,
+		OwnerID:   s.OwnerID,
+		UserID:    s.UserID,
+		Role:      s.Role,
+		Version:   s.Version,
+		Revoked:   s.Revoked,
+		UpdatedAt: s.UpdatedAt.Format(time.RFC3339),
+	}
+}
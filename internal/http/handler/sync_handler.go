@@ -0,0 +1,269 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"net/http"
+	"strconv"
+)
+
+// SyncHandler serves /changes, merging every synced entity into one feed.
+type SyncHandler struct {
+	items       repository.ItemRepository
+	tags        repository.TagRepository
+	collections repository.CollectionRepository
+	attachments repository.AttachmentRepository
+	shares      repository.ShareRepository
+	workspaces  repository.WorkspaceRepository
+	comments    repository.CommentRepository
+}
+
+func NewSyncHandler(
+	items repository.ItemRepository,
+	tags repository.TagRepository,
+	collections repository.CollectionRepository,
+	attachments repository.AttachmentRepository,
+	shares repository.ShareRepository,
+	workspaces repository.WorkspaceRepository,
+	comments repository.CommentRepository,
+) *SyncHandler {
+	return &SyncHandler{
+		items:       items,
+		tags:        tags,
+		collections: collections,
+		attachments: attachments,
+		shares:      shares,
+		workspaces:  workspaces,
+		comments:    comments,
+	}
+}
+
+type ChangeResponse struct {
+	LatestVersion int                  `json:"latest_version"`
+	Items         []ItemResponse       `json:"items"`
+	Tags          []TagResponse        `json:"tags"`
+	ItemTags      []ItemTagResponse    `json:"item_tags"`
+	Collections   []CollectionResponse `json:"collections"`
+	Attachments   []AttachmentResponse `json:"attachments"`
+	Shares        []ShareResponse      `json:"shares"`
+	// AccessRevoked tells a former collaborator to drop a shared item.
+	AccessRevoked    []AccessRevokedResponse `json:"access_revoked"`
+	Workspaces       []WorkspaceResponse     `json:"workspaces"`
+	WorkspaceMembers []MemberResponse        `json:"workspace_members"`
+	Comments         []CommentResponse       `json:"comments"`
+}
+
+// WorkspaceChangeResponse is the feed of one team workspace; its
+// versions come from the workspace's own counter.
+type WorkspaceChangeResponse struct {
+	WorkspaceID   string            `json:"workspace_id"`
+	LatestVersion int               `json:"latest_version"`
+	Items         []ItemResponse    `json:"items"`
+	Comments      []CommentResponse `json:"comments"`
+}
+
+type AccessRevokedResponse struct {
+	ItemID  string `json:"item_id"`
+	Version int    `json:"version"`
+}
+
+func (h *SyncHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	sinceStr := r.URL.Query().Get("since_version")
+	if sinceStr == "" {
+		writeProblem(w, r, problem.BadRequest("since_version is required!"))
+		return
+	}
+
+	sinceVersion, err := strconv.Atoi(sinceStr)
+	if err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid since_version!"))
+		return
+	}
+
+	if workspaceID := r.URL.Query().Get("workspace_id"); workspaceID != "" && workspaceID != userID {
+		h.getWorkspaceChanges(w, r, userID, workspaceID, sinceVersion)
+		return
+	}
+
+	// the queries below are separate reads; bounding them all by the
+	// watermark read first keeps the page consistent
+	watermark, err := h.items.LatestVersion(r.Context(), "")
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items, _, err := h.items.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tags, itemTags, _, err := h.tags.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	collections, _, err := h.collections.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	attachments, _, err := h.attachments.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	shares, _, err := h.shares.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	workspaces, members, _, err := h.workspaces.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comments, _, err := h.comments.GetChanges(r.Context(), userID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items = upTo(items, watermark, func(i *domain.Item) int { return i.Version })
+	tags = upTo(tags, watermark, func(t *domain.Tag) int { return t.Version })
+	itemTags = upTo(itemTags, watermark, func(it *domain.ItemTag) int { return it.Version })
+	collections = upTo(collections, watermark, func(c *domain.Collection) int { return c.Version })
+	attachments = upTo(attachments, watermark, func(a *domain.Attachment) int { return a.Version })
+	shares = upTo(shares, watermark, func(s *domain.Share) int { return s.Version })
+	workspaces = upTo(workspaces, watermark, func(ws *domain.Workspace) int { return ws.Version })
+	members = upTo(members, watermark, func(m *domain.WorkspaceMember) int { return m.Version })
+	comments = upTo(comments, watermark, func(c *domain.Comment) int { return c.Version })
+
+	resp := ChangeResponse{
+		LatestVersion:    max(sinceVersion, watermark),
+		Items:            make([]ItemResponse, 0, len(items)),
+		Tags:             make([]TagResponse, 0, len(tags)),
+		ItemTags:         make([]ItemTagResponse, 0, len(itemTags)),
+		Collections:      make([]CollectionResponse, 0, len(collections)),
+		Attachments:      make([]AttachmentResponse, 0, len(attachments)),
+		Shares:           make([]ShareResponse, 0, len(shares)),
+		AccessRevoked:    []AccessRevokedResponse{},
+		Workspaces:       make([]WorkspaceResponse, 0, len(workspaces)),
+		WorkspaceMembers: make([]MemberResponse, 0, len(members)),
+		Comments:         make([]CommentResponse, 0, len(comments)),
+	}
+
+	for _, item := range items {
+		resp.Items = append(resp.Items, toItemResponse(item))
+	}
+	for _, tag := range tags {
+		resp.Tags = append(resp.Tags, toTagResponse(tag))
+	}
+	for _, it := range itemTags {
+		resp.ItemTags = append(resp.ItemTags, toItemTagResponse(it))
+	}
+	for _, c := range collections {
+		resp.Collections = append(resp.Collections, toCollectionResponse(c))
+	}
+	for _, a := range attachments {
+		resp.Attachments = append(resp.Attachments, toAttachmentResponse(a))
+	}
+	for _, s := range shares {
+		// a collaborator only learns that access is gone, not the tombstone itself
+		if s.Revoked && s.UserID == userID {
+			resp.AccessRevoked = append(resp.AccessRevoked, AccessRevokedResponse{ItemID: s.ItemID, Version: s.Version})
+			continue
+		}
+		resp.Shares = append(resp.Shares, toShareResponse(s))
+	}
+
+	for _, ws := range workspaces {
+		resp.Workspaces = append(resp.Workspaces, toWorkspaceResponse(ws))
+	}
+	for _, m := range members {
+		resp.WorkspaceMembers = append(resp.WorkspaceMembers, toMemberResponse(m))
+	}
+	for _, c := range comments {
+		resp.Comments = append(resp.Comments, toCommentResponse(c))
+	}
+
+	writeJSON(w, resp)
+}
+
+// getWorkspaceChanges serves /changes?workspace_id= for team workspaces.
+func (h *SyncHandler) getWorkspaceChanges(w http.ResponseWriter, r *http.Request, userID, workspaceID string, sinceVersion int) {
+	if err := requireUUIDs("workspace_id", workspaceID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if _, err := h.workspaces.Role(r.Context(), workspaceID, userID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	watermark, err := h.items.LatestVersion(r.Context(), workspaceID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items, _, err := h.items.GetWorkspaceChanges(r.Context(), workspaceID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	comments, _, err := h.comments.GetWorkspaceChanges(r.Context(), workspaceID, sinceVersion)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items = upTo(items, watermark, func(i *domain.Item) int { return i.Version })
+	comments = upTo(comments, watermark, func(c *domain.Comment) int { return c.Version })
+
+	resp := WorkspaceChangeResponse{
+		WorkspaceID:   workspaceID,
+		LatestVersion: max(sinceVersion, watermark),
+		Items:         make([]ItemResponse, 0, len(items)),
+		Comments:      make([]CommentResponse, 0, len(comments)),
+	}
+	for _, item := range items {
+		resp.Items = append(resp.Items, toItemResponse(item))
+	}
+	for _, c := range comments {
+		resp.Comments = append(resp.Comments, toCommentResponse(c))
+	}
+
+	writeJSON(w, resp)
+}
+
+/*
+upTo drops rows written after the watermark. latest_version stops at
+the watermark, since a lower version may still be committing above it,
+so those rows come back on the next pull anyway.
+*/
+func upTo[T any](rows []T, watermark int, version func(T) int) []T {
+	kept := rows[:0]
+	for _, row := range rows {
+		if version(row) <= watermark {
+			kept = append(kept, row)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,269 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TagHandler struct {
+	repo repository.TagRepository
+}
+
+func NewTagHandler(repo repository.TagRepository) *TagHandler {
+	return &TagHandler{repo: repo}
+}
+
+type TagRequest struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+type TagResponse struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	Name      string `json:"name"`
+	Version   int    `json:"version"`
+	Deleted   bool   `json:"deleted"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type AssignTagRequest struct {
+	Version int `json:"version"`
+}
+
+type ItemTagResponse struct {
+	ItemID    string `json:"item_id"`
+	TagID     string `json:"tag_id"`
+	Version   int    `json:"version"`
+	Deleted   bool   `json:"deleted"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+func (h *TagHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	tag := &domain.Tag{ID: req.ID, UserID: userID, Name: strings.TrimSpace(req.Name)}
+	if err := validateTag(tag); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling tag create request", "tag_id", tag.ID)
+
+	created, err := h.repo.Create(r.Context(), tag, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toTagResponse(created))
+}
+
+func (h *TagHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	tags, err := h.repo.ListByUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		resp = append(resp, toTagResponse(tag))
+	}
+
+	writeJSON(w, resp)
+}
+
+func (h *TagHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/tags/")
+	if id == "" {
+		writeProblem(w, r, problem.BadRequest("id required"))
+		return
+	}
+
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	tag := &domain.Tag{ID: id, UserID: userID, Name: strings.TrimSpace(req.Name), Version: req.Version}
+	if err := validateTag(tag); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling tag rename request", "tag_id", id, "base_version", req.Version)
+
+	renamed, err := h.repo.Rename(r.Context(), tag, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toTagResponse(renamed))
+}
+
+func (h *TagHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/tags/")
+	if id == "" {
+		writeProblem(w, r, problem.BadRequest("id required"))
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling tag delete request", "tag_id", id, "base_version", version)
+
+	deleted, err := h.repo.SoftDelete(r.Context(), id, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toTagResponse(deleted))
+}
+
+// Assign handles PUT /items/{id}/tags/{tag_id}.
+func (h *TagHandler) Assign(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req AssignTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	assignment := &domain.ItemTag{
+		ItemID:  r.PathValue("id"),
+		TagID:   r.PathValue("sub_id"),
+		UserID:  userID,
+		Version: req.Version,
+	}
+	if err := requireUUIDs("item_id", assignment.ItemID, "tag_id", assignment.TagID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling tag assign request",
+		"item_id", assignment.ItemID,
+		"tag_id", assignment.TagID,
+		"base_version", req.Version,
+	)
+
+	assigned, err := h.repo.Assign(r.Context(), assignment, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toItemTagResponse(assigned))
+}
+
+// Unassign handles DELETE /items/{id}/tags/{tag_id}?version=.
+func (h *TagHandler) Unassign(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	itemID, tagID := r.PathValue("id"), r.PathValue("sub_id")
+	if err := requireUUIDs("item_id", itemID, "tag_id", tagID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling tag unassign request",
+		"item_id", itemID,
+		"tag_id", tagID,
+		"base_version", version,
+	)
+
+	unassigned, err := h.repo.Unassign(r.Context(), itemID, tagID, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toItemTagResponse(unassigned))
+}
+
+func validateTag(tag *domain.Tag) error {
+	verr := domain.NewValidationError()
+	if _, err := uuid.Parse(tag.ID); err != nil {
+		verr.Add("id", "must be a UUID")
+	}
+	if tag.Name == "" {
+		verr.Add("name", "is required")
+	}
+	if len(tag.Name) > 128 {
+		verr.Add("name", "must be at most 128 bytes")
+	}
+	return verr.OrNil()
+}
+
+func toTagResponse(tag *domain.Tag) TagResponse {
+	return TagResponse{
+		ID:        tag.ID,
+		UserID:    tag.UserID,
+		Name:      tag.Name,
+		Version:   tag.Version,
+		Deleted:   tag.Deleted,
+		UpdatedAt: tag.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func toItemTagResponse(it *domain.ItemTag) ItemTagResponse {
+	return ItemTagResponse{
+		ItemID:    it.ItemID,
+		TagID:     it.TagID,
+		Version:   it.Version,
+		Deleted:   it.Deleted,
+		UpdatedAt: it.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,319 @@
+package handler
+
+import (
+	"Offline-First/internal/blob"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+/*
+UploadHandler implements resumable, content-addressed uploads:
+
+ 1. POST  /uploads        declare sha256 + size; returns the upload (or
+    status "complete" if this user already stored the same bytes)
+ 2. PATCH /uploads/{id}   send a chunk at Upload-Offset; repeat
+ 3. GET   /uploads/{id}   current offset, to resume after a disconnect
+
+When the last byte arrives the digest is verified and the blob moves
+to the Store. GET /blobs/{sha256} downloads it.
+*/
+type UploadHandler struct {
+	repo         repository.BlobRepository
+	store        blob.Store
+	staging      *blob.Staging
+	maxBlobBytes int64
+}
+
+func NewUploadHandler(repo repository.BlobRepository, store blob.Store, staging *blob.Staging, maxBlobBytes int64) *UploadHandler {
+	return &UploadHandler{repo: repo, store: store, staging: staging, maxBlobBytes: maxBlobBytes}
+}
+
+type StartUploadRequest struct {
+	SHA256      string `json:"sha256"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+type UploadResponse struct {
+	UploadID string `json:"upload_id,omitempty"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Offset   int64  `json:"offset"`
+	Status   string `json:"status"` // pending | complete
+}
+
+const (
+	uploadPending  = "pending"
+	uploadComplete = "complete"
+)
+
+func (h *UploadHandler) Start(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	var req StartUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	req.SHA256 = strings.ToLower(req.SHA256)
+	if req.ContentType == "" {
+		req.ContentType = "application/octet-stream"
+	}
+
+	verr := domain.NewValidationError()
+	if !validSHA256(req.SHA256) {
+		verr.Add("sha256", "must be 64 hex characters")
+	}
+	if req.Size < 1 || req.Size > h.maxBlobBytes {
+		verr.Add("size", "must be between 1 and "+strconv.FormatInt(h.maxBlobBytes, 10)+" bytes")
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// deduplicate: same user, same bytes → nothing to upload
+	existing, err := h.repo.GetBlob(r.Context(), userID, req.SHA256)
+	if err == nil {
+		writeJSON(w, UploadResponse{
+			SHA256: existing.SHA256,
+			Size:   existing.Size,
+			Offset: existing.Size,
+			Status: uploadComplete,
+		})
+		return
+	}
+	if err != domain.ErrNotFound {
+		writeError(w, r, err)
+		return
+	}
+
+	upload, err := h.repo.CreateUpload(r.Context(), &domain.Upload{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		SHA256:      req.SHA256,
+		Size:        req.Size,
+		ContentType: req.ContentType,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "upload started", "upload_id", upload.ID, "sha256", upload.SHA256, "size", upload.Size)
+
+	w.Header().Set("Location", "/uploads/"+upload.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(toUploadResponse(upload))
+}
+
+func (h *UploadHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/uploads/")
+	if err := requireUUIDs("upload_id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	upload, err := h.repo.GetUpload(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+	writeJSON(w, toUploadResponse(upload))
+}
+
+// Append handles PATCH /uploads/{id} with an Upload-Offset header.
+func (h *UploadHandler) Append(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/uploads/")
+	if err := requireUUIDs("upload_id", id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		writeProblem(w, r, problem.BadRequest("Upload-Offset header is required"))
+		return
+	}
+
+	upload, err := h.repo.GetUpload(r.Context(), userID, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	// the client must resume exactly where the server is
+	if offset != upload.Received {
+		writeError(w, r, domain.NewEntityConflictError(upload))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, upload.Size-offset)
+	written, err := h.staging.WriteAt(upload.ID, offset, body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeProblem(w, r, problem.BadRequest("chunk exceeds declared size"))
+			return
+		}
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+
+	upload, err = h.repo.AdvanceUpload(r.Context(), userID, id, offset, offset+written)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if !upload.Complete() {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(upload.Received, 10))
+		writeJSON(w, toUploadResponse(upload))
+		return
+	}
+
+	h.finish(w, r, upload)
+}
+
+// finish verifies the digest and hands the bytes to the Store.
+func (h *UploadHandler) finish(w http.ResponseWriter, r *http.Request, upload *domain.Upload) {
+	digest, err := h.staging.Digest(upload.ID)
+	if err != nil {
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+
+	if digest != upload.SHA256 {
+		middleware.LogWithContext(r.Context(), "upload digest mismatch", "upload_id", upload.ID, "expected", upload.SHA256, "actual", digest)
+		h.repo.DeleteUpload(r.Context(), upload.UserID, upload.ID)
+		h.staging.Remove(upload.ID)
+		writeError(w, r, domain.NewValidationError(domain.FieldError{
+			Field:   "sha256",
+			Message: "does not match the uploaded bytes; start a new upload",
+		}))
+		return
+	}
+
+	staged, err := h.staging.Open(upload.ID)
+	if err != nil {
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+	err = h.store.Put(r.Context(), blob.Key(upload.UserID, upload.SHA256), staged)
+	staged.Close()
+	if err != nil {
+		// received == size, so re-sending an empty PATCH retries this step
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+
+	stored, err := h.repo.CompleteUpload(r.Context(), upload)
+	if err != nil {
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+	h.staging.Remove(upload.ID)
+
+	middleware.LogWithContext(r.Context(), "upload complete", "upload_id", upload.ID, "sha256", stored.SHA256)
+
+	writeJSON(w, UploadResponse{
+		SHA256: stored.SHA256,
+		Size:   stored.Size,
+		Offset: stored.Size,
+		Status: uploadComplete,
+	})
+}
+
+// Download handles GET /blobs/{sha256}.
+func (h *UploadHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	sha := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/blobs/"))
+	if !validSHA256(sha) {
+		writeProblem(w, r, problem.BadRequest("invalid sha256"))
+		return
+	}
+
+	b, err := h.repo.GetBlob(r.Context(), userID, sha)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	etag := `"` + b.SHA256 + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	content, err := h.store.Open(r.Context(), blob.Key(userID, sha))
+	if err == blob.ErrNotFound {
+		writeError(w, r, domain.ErrNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, r, domain.Retryable(err))
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", b.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(b.Size, 10))
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	io.Copy(w, content)
+}
+
+func validSHA256(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// toUploadResponse renders an open session; finished uploads have no session row.
+func toUploadResponse(u *domain.Upload) UploadResponse {
+	return UploadResponse{
+		UploadID: u.ID,
+		SHA256:   u.SHA256,
+		Size:     u.Size,
+		Offset:   u.Received,
+		Status:   uploadPending,
+	}
+}
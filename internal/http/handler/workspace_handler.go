@@ -0,0 +1,277 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/repository"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WorkspaceHandler struct {
+	repo repository.WorkspaceRepository
+}
+
+func NewWorkspaceHandler(repo repository.WorkspaceRepository) *WorkspaceHandler {
+	return &WorkspaceHandler{repo: repo}
+}
+
+// WorkspaceRequest has no id: workspace IDs are generated by the server,
+// since a chosen ID could collide with a user's personal workspace.
+type WorkspaceRequest struct {
+	Name    string `json:"name"`
+	Version int    `json:"version"`
+}
+
+type WorkspaceResponse struct {
+	ID        string `json:"id"`
+	OwnerID   string `json:"owner_id"`
+	Name      string `json:"name"`
+	Personal  bool   `json:"personal"`
+	Version   int    `json:"version"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+type MemberRequest struct {
+	Role    string `json:"role"`
+	Version int    `json:"version"` // 0 when the user was never a member
+}
+
+type MemberResponse struct {
+	WorkspaceID string `json:"workspace_id"`
+	UserID      string `json:"user_id"`
+	Role        string `json:"role"`
+	Version     int    `json:"version"`
+	Removed     bool   `json:"removed"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+// Create handles POST /workspaces.
+func (h *WorkspaceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	ws := &domain.Workspace{ID: uuid.NewString(), OwnerID: userID, Name: strings.TrimSpace(req.Name)}
+	if err := validateWorkspace(ws); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling workspace create request", "workspace_id", ws.ID)
+
+	created, err := h.repo.Create(r.Context(), ws, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toWorkspaceResponse(created))
+}
+
+// List handles GET /workspaces.
+func (h *WorkspaceHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	workspaces, err := h.repo.ListForUser(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]WorkspaceResponse, 0, len(workspaces))
+	for _, ws := range workspaces {
+		resp = append(resp, toWorkspaceResponse(ws))
+	}
+	writeJSON(w, resp)
+}
+
+// Rename handles PUT /workspaces/{id}.
+func (h *WorkspaceHandler) Rename(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req WorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	ws := &domain.Workspace{ID: r.PathValue("id"), Name: strings.TrimSpace(req.Name), Version: req.Version}
+	if err := validateWorkspace(ws); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling workspace rename request", "workspace_id", ws.ID, "base_version", ws.Version)
+
+	renamed, err := h.repo.Rename(r.Context(), ws, userID, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toWorkspaceResponse(renamed))
+}
+
+// SetMember handles PUT /workspaces/{id}/members/{user_id}.
+func (h *WorkspaceHandler) SetMember(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	var req MemberRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeProblem(w, r, problem.BadRequest("invalid json"))
+		return
+	}
+
+	m := &domain.WorkspaceMember{
+		WorkspaceID: r.PathValue("id"),
+		UserID:      r.PathValue("sub_id"),
+		Role:        req.Role,
+		Version:     req.Version,
+	}
+
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("workspace_id", m.WorkspaceID, "user_id", m.UserID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if !domain.ValidWorkspaceRole(m.Role) {
+		verr.Add("role", "must be owner, editor or viewer")
+	}
+	if err := verr.OrNil(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling member set request",
+		"workspace_id", m.WorkspaceID,
+		"member_id", m.UserID,
+		"role", m.Role,
+		"base_version", m.Version,
+	)
+
+	updated, err := h.repo.SetMember(r.Context(), m, userID, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toMemberResponse(updated))
+}
+
+// RemoveMember handles DELETE /workspaces/{id}/members/{user_id}?version=.
+func (h *WorkspaceHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, mutationID, ok := mutationContext(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := versionFromQuery(w, r)
+	if !ok {
+		return
+	}
+
+	workspaceID, memberID := r.PathValue("id"), r.PathValue("sub_id")
+	if err := requireUUIDs("workspace_id", workspaceID, "user_id", memberID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	middleware.LogWithContext(r.Context(), "handling member remove request",
+		"workspace_id", workspaceID,
+		"member_id", memberID,
+		"base_version", version,
+	)
+
+	removed, err := h.repo.RemoveMember(r.Context(), workspaceID, memberID, userID, version, mutationID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, toMemberResponse(removed))
+}
+
+// ListMembers handles GET /workspaces/{id}/members.
+func (h *WorkspaceHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		writeProblem(w, r, problem.Unauthorized("unauthorized"))
+		return
+	}
+
+	workspaceID := r.PathValue("id")
+	if err := requireUUIDs("workspace_id", workspaceID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	members, err := h.repo.ListMembers(r.Context(), workspaceID, userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	resp := make([]MemberResponse, 0, len(members))
+	for _, m := range members {
+		resp = append(resp, toMemberResponse(m))
+	}
+	writeJSON(w, resp)
+}
+
+func validateWorkspace(ws *domain.Workspace) error {
+	verr := domain.NewValidationError()
+	if err := requireUUIDs("id", ws.ID); err != nil {
+		verr.Fields = append(verr.Fields, err.(*domain.ValidationError).Fields...)
+	}
+	if ws.Name == "" || len(ws.Name) > 200 {
+		verr.Add("name", "must be 1-200 bytes")
+	}
+	return verr.OrNil()
+}
+
+func toWorkspaceResponse(ws *domain.Workspace) WorkspaceResponse {
+	resp := WorkspaceResponse{
+		ID:       ws.ID,
+		OwnerID:  ws.OwnerID,
+		Name:     ws.Name,
+		Personal: ws.Personal,
+		Version:  ws.Version,
+	}
+	if !ws.UpdatedAt.IsZero() {
+		resp.UpdatedAt = ws.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+func toMemberResponse(m *domain.WorkspaceMember) MemberResponse {
+	return MemberResponse{
+		WorkspaceID: m.WorkspaceID,
+		UserID:      m.UserID,
+		Role:        m.Role,
+		Version:     m.Version,
+		Removed:     m.Removed,
+		UpdatedAt:   m.UpdatedAt.Format(time.RFC3339),
+	}
+}
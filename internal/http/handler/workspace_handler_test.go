@@ -0,0 +1,66 @@
+package handler
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/repository"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// workspaceRepoStub records the workspace handed to Create.
+type workspaceRepoStub struct {
+	repository.WorkspaceRepository
+	created *domain.Workspace
+}
+
+func (s *workspaceRepoStub) Create(_ context.Context, ws *domain.Workspace, _ string) (*domain.Workspace, error) {
+	s.created = ws
+	return ws, nil
+}
+
+// A client-chosen id must not let the caller own another user's
+// personal workspace (whose id is that user's ID).
+func TestWorkspaceCreateIgnoresClientID(t *testing.T) {
+	attacker, victim := uuid.NewString(), uuid.NewString()
+	repo := &workspaceRepoStub{}
+	h := NewWorkspaceHandler(repo)
+
+	body := `{"id":"` + victim + `","name":"Team"}`
+	r := httptest.NewRequest(http.MethodPost, "/workspaces", strings.NewReader(body))
+	r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, attacker))
+	w := httptest.NewRecorder()
+	middleware.MutationMiddleware(http.HandlerFunc(h.Create)).ServeHTTP(w, withMutationID(r))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body)
+	}
+	if repo.created == nil {
+		t.Fatal("repository Create not called")
+	}
+	if repo.created.ID == victim || repo.created.ID == attacker {
+		t.Fatalf("workspace id %s collides with a personal workspace", repo.created.ID)
+	}
+	if _, err := uuid.Parse(repo.created.ID); err != nil {
+		t.Fatalf("workspace id %q is not a UUID", repo.created.ID)
+	}
+
+	var resp WorkspaceResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ID != repo.created.ID || resp.OwnerID != attacker {
+		t.Fatalf("response = %+v", resp)
+	}
+}
+
+func withMutationID(r *http.Request) *http.Request {
+	r.Header.Set("X-MUTATION-ID", uuid.NewString())
+	return r
+}
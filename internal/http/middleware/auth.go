@@ -1,21 +1,101 @@
 package middleware
 
 import (
+	"Offline-First/internal/audit"
+	"Offline-First/internal/auth"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/problem"
 	"context"
-	"log"
+	"errors"
 	"net/http"
+
+	"github.com/google/uuid"
 )
 
-func Auth(next http.Handler) http.Handler {
+/*
+Auth resolves the caller with authn and stores the user (and device)
+ID in the request context. Failures are 401 with a Bearer challenge;
+the reason is logged but only a generic detail is returned, except
+for revoked devices and erased accounts which get their own code.
+Erasure is checked here for every principal, so an external JWT or
+dev header naming an erased user cannot recreate data either.
+
+Every attempt, successful or not, is recorded in the audit log.
+*/
+func Auth(authn auth.Authenticator, erasures auth.ErasureStore, recorder *audit.Recorder, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Header.Get("X-User-ID")
-		log.Println("X-User-ID:", r.Header.Get("X-User-ID"))
-		if userID == "" {
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		principal, err := authn.Authenticate(r)
+		if err == nil {
+			// the nil UUID owns erasure tombstones
+			if id, parseErr := uuid.Parse(principal.UserID); parseErr != nil || id == uuid.Nil {
+				err = errors.New("user id is not a UUID")
+			}
+		}
+		if err == nil {
+			erased, lookupErr := erasures.AccountErased(r.Context(), principal.UserID)
+			if lookupErr != nil {
+				LogWithContext(r.Context(), "erasure lookup failed", "path", r.URL.Path, "error", lookupErr)
+				problem.FromError(lookupErr).Write(w)
+				return
+			}
+			if erased {
+				err = domain.ErrAccountDeleted
+			}
+		}
+
+		if err != nil {
+			LogWithContext(r.Context(), "authentication failed", "path", r.URL.Path, "reason", err)
+
+			e := authAuditEvent(r, nil)
+			e.Outcome = domain.AuditFailure
+			e.Detail["reason"] = err.Error()
+			recorder.Record(e)
+
+			switch {
+			case errors.Is(err, auth.ErrNoCredentials):
+				w.Header().Set("WWW-Authenticate", `Bearer`)
+				problem.Unauthorized("authentication required").Write(w)
+			case errors.Is(err, domain.ErrDeviceRevoked), errors.Is(err, domain.ErrAccountDeleted):
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				problem.FromError(err).Write(w)
+			default:
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				problem.Unauthorized("invalid or expired token").Write(w)
+			}
 			return
 		}
-		ctx := context.WithValue(r.Context(), UserIDKey, userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
 
+		recorder.Record(authAuditEvent(r, principal))
+
+		ctx := context.WithValue(r.Context(), UserIDKey, principal.UserID)
+		ctx = context.WithValue(ctx, PrincipalKey, principal)
+		if principal.DeviceID != "" {
+			ctx = context.WithValue(ctx, DeviceIDKey, principal.DeviceID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// authAuditEvent describes an authentication attempt; principal is nil on failure.
+func authAuditEvent(r *http.Request, principal *auth.Principal) *domain.AuditEvent {
+	e := &domain.AuditEvent{
+		Category:   domain.AuditAuth,
+		Action:     "authenticate",
+		RemoteAddr: r.RemoteAddr,
+		Detail:     map[string]any{"method": r.Method, "path": r.URL.Path},
+	}
+	if principal == nil {
+		return e
+	}
+
+	e.UserID, e.DeviceID, e.APIKeyID = principal.UserID, principal.DeviceID, principal.APIKeyID
+	switch {
+	case principal.APIKeyID != "":
+		e.Detail["credential"] = "api_key"
+	case principal.DeviceID != "":
+		e.Detail["credential"] = "device_token"
+	default:
+		e.Detail["credential"] = "bearer"
+	}
+	return e
+}
@@ -1,8 +1,11 @@
 package middleware
 
-
 type contextKey string
 
 const UserIDKey contextKey = "userID"
 
-const MutationIDKey contextKey = "mutationID"
\ No newline at end of file
+const MutationIDKey contextKey = "mutationID"
+
+const DeviceIDKey contextKey = "deviceID"
+
+const PrincipalKey contextKey = "principal"
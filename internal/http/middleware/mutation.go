@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"Offline-First/internal/http/problem"
 	"context"
 	"net/http"
 
@@ -15,13 +16,13 @@ func MutationMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		mutationIDStr := r.Header.Get("X-MUTATION-ID")
 		if mutationIDStr == "" {
-			http.Error(w, "X-MUTATION-ID header is required", http.StatusBadRequest)
+			problem.BadRequest("X-MUTATION-ID header is required").Write(w)
 			return
 		}
 
 		mutationID, err := uuid.Parse(mutationIDStr)
 		if err != nil {
-			http.Error(w, "invalid X-MUTATION-ID", http.StatusBadRequest)
+			problem.BadRequest("invalid X-MUTATION-ID").Write(w)
 			return
 		}
 
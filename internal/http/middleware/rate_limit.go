@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"Offline-First/internal/http/problem"
+	"Offline-First/internal/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RateLimits holds the separate budgets; a nil limiter leaves that class unlimited.
+type RateLimits struct {
+	// Mutations covers every non-GET / HEAD request, per device or API key.
+	Mutations *ratelimit.Limiter
+	// Changes covers GET /changes polling, per device or API key.
+	Changes *ratelimit.Limiter
+	// UserMutations and UserChanges cap the same classes per user across
+	// all credentials, so adding devices or keys does not add budget.
+	UserMutations *ratelimit.Limiter
+	UserChanges   *ratelimit.Limiter
+}
+
+/*
+RateLimit throttles mutations and /changes per device (or API key) and
+per user, so one looping client cannot exhaust the database pool for
+everyone and a user cannot multiply their budget with more credentials.
+Other reads are not limited. Rejected requests cost no tokens and get
+429 rate_limited with Retry-After and retryable set, which offline
+queues treat like any other back-off.
+
+Must run after Auth.
+*/
+func RateLimit(limits RateLimits, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var limiter, userLimiter *ratelimit.Limiter
+		switch {
+		case r.URL.Path == "/changes":
+			limiter, userLimiter = limits.Changes, limits.UserChanges
+		case r.Method != http.MethodGet && r.Method != http.MethodHead:
+			limiter, userLimiter = limits.Mutations, limits.UserMutations
+		}
+
+		userKey, credentialKey := rateLimitKeys(r)
+		ok, wait := limiter.Allow(credentialKey)
+		if ok {
+			if ok, wait = userLimiter.Allow(userKey); !ok {
+				limiter.Refund(credentialKey)
+			}
+		}
+		if !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			LogWithContext(r.Context(), "rate limited", "path", r.URL.Path, "retry_after", seconds)
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			problem.RateLimited(time.Duration(seconds) * time.Second).Write(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKeys returns the user and the credential key: user/device,
+// user/api-key or the bare user.
+func rateLimitKeys(r *http.Request) (string, string) {
+	principal, ok := PrincipalFromContext(r.Context())
+	if !ok {
+		return "", ""
+	}
+	switch {
+	case principal.DeviceID != "":
+		return principal.UserID, principal.UserID + "/device/" + principal.DeviceID
+	case principal.APIKeyID != "":
+		return principal.UserID, principal.UserID + "/key/" + principal.APIKeyID
+	default:
+		return principal.UserID, principal.UserID
+	}
+}
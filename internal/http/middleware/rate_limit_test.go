@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"Offline-First/internal/auth"
+	"Offline-First/internal/ratelimit"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// A request the user bucket rejects must not spend the device's token.
+func TestRateLimitUserRejectionCostsNoCredentialToken(t *testing.T) {
+	limits := RateLimits{
+		Mutations:     ratelimit.NewLimiter(0.001, 2),
+		UserMutations: ratelimit.NewLimiter(0.001, 1),
+	}
+	h := RateLimit(limits, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	send := func(deviceID string) int {
+		p := &auth.Principal{UserID: "user", DeviceID: deviceID}
+		r := httptest.NewRequest(http.MethodPost, "/items", nil)
+		r = r.WithContext(context.WithValue(r.Context(), PrincipalKey, p))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := send("a"); code != http.StatusOK {
+		t.Fatalf("first request: status = %d", code)
+	}
+	for i := 0; i < 2; i++ {
+		if code := send("b"); code != http.StatusTooManyRequests {
+			t.Fatalf("request %d from b: status = %d, want 429", i, code)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limits.Mutations.Allow("user/device/b"); !ok {
+			t.Fatalf("device b lost token %d to rejected requests", i)
+		}
+	}
+}
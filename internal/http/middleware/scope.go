@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"Offline-First/internal/http/problem"
+	"net/http"
+)
+
+/*
+Scoped lets a request through when the caller's principal allows the
+read scope (GET / HEAD) or the write scope (any other method), and
+answers 403 insufficient_scope otherwise.
+*/
+func Scoped(read, write string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scope := write
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			scope = read
+		}
+
+		principal, ok := PrincipalFromContext(r.Context())
+		if !ok || !principal.Allows(scope) {
+			LogWithContext(r.Context(), "scope denied", "path", r.URL.Path, "scope", scope)
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			problem.InsufficientScope(scope).Write(w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,8 +1,22 @@
 package middleware
 
-import "context"
+import (
+	"Offline-First/internal/auth"
+	"context"
+)
 
 func UserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
 }
+
+// DeviceIDFromContext is set only for callers using a device access token.
+func DeviceIDFromContext(ctx context.Context) (string, bool) {
+	deviceID, ok := ctx.Value(DeviceIDKey).(string)
+	return deviceID, ok
+}
+
+func PrincipalFromContext(ctx context.Context) (*auth.Principal, bool) {
+	p, ok := ctx.Value(PrincipalKey).(*auth.Principal)
+	return p, ok
+}
@@ -0,0 +1,182 @@
+package problem
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+/*
+Problem is the single error envelope returned by every endpoint.
+
+It is serialised as application/problem+json (RFC 9457) with a few
+extension members the offline clients rely on:
+
+- code:        stable, machine-readable error code
+- retryable:   the client may replay the same mutation later
+- conflict:    the client must resolve a version conflict
+- mutation_id: echo of X-MUTATION-ID so queued mutations can be matched
+*/
+type Problem struct {
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Status     int    `json:"status"`
+	Detail     string `json:"detail,omitempty"`
+	Code       string `json:"code"`
+	Retryable  bool   `json:"retryable"`
+	Conflict   bool   `json:"conflict"`
+	MutationID string `json:"mutation_id,omitempty"`
+
+	// ServerItem carries the authoritative server state on version conflicts.
+	ServerItem any `json:"server_item,omitempty"`
+
+	// ServerState carries the server copy of non-item entities on conflicts.
+	ServerState any `json:"server_state,omitempty"`
+
+	// Errors lists field-level problems for validation_failed.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+const ContentType = "application/problem+json"
+
+/*
+========================
+
+	Error codes
+
+========================
+*/
+const (
+	CodeNotFound          = "not_found"
+	CodeAlreadyExists     = "already_exists"
+	CodeVersionConflict   = "version_conflict"
+	CodeCycleConflict     = "cycle_conflict"
+	CodeInvalidRequest    = "invalid_request"
+	CodeValidationFailed  = "validation_failed"
+	CodeRetryable         = "retryable"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeDeviceRevoked     = "device_revoked"
+	CodeAccountDeleted    = "account_deleted"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeRateLimited       = "rate_limited"
+	CodeQuotaExceeded     = "quota_exceeded"
+	CodeMethodNotAllowed  = "method_not_allowed"
+	CodeInternal          = "internal_error"
+)
+
+func New(status int, code string, detail string) *Problem {
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// BadRequest is a 400 for requests that cannot be read at all (malformed
+// JSON, missing headers); field-level failures are 422 validation_failed.
+func BadRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidRequest, detail)
+}
+
+func Unauthorized(detail string) *Problem {
+	return New(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+// InsufficientScope is a 403 for credentials that lack a route's scope;
+// forbidden is kept for role checks on a specific entity.
+func InsufficientScope(scope string) *Problem {
+	return New(http.StatusForbidden, CodeInsufficientScope, "credentials lack the "+scope+" scope")
+}
+
+// RateLimited is a retryable 429; the caller sets Retry-After.
+func RateLimited(retryAfter time.Duration) *Problem {
+	p := New(http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded, retry in "+retryAfter.String())
+	p.Retryable = true
+	return p
+}
+
+func NotFound(detail string) *Problem {
+	return New(http.StatusNotFound, CodeNotFound, detail)
+}
+
+func MethodNotAllowed() *Problem {
+	return New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "method not allowed")
+}
+
+/*
+FromError maps a repository / domain error onto the envelope.
+
+The retryable and conflict flags always come from domain.MutationError,
+so the HTTP layer never re-derives them.
+*/
+func FromError(err error) *Problem {
+	var p *Problem
+
+	var conflict *domain.ConflictError
+	var cycle *domain.CycleConflictError
+	var invalid *domain.ValidationError
+	var quota *domain.QuotaExceededError
+	switch {
+	case errors.As(err, &invalid):
+		p = New(http.StatusUnprocessableEntity, CodeValidationFailed, err.Error())
+		for _, f := range invalid.Fields {
+			p.Errors = append(p.Errors, FieldError{Field: f.Field, Message: f.Message})
+		}
+	case errors.As(err, &conflict):
+		p = New(http.StatusConflict, CodeVersionConflict, err.Error())
+	case errors.As(err, &cycle):
+		p = New(http.StatusConflict, CodeCycleConflict, err.Error())
+	case errors.As(err, &quota):
+		p = New(http.StatusForbidden, CodeQuotaExceeded, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		p = New(http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		p = New(http.StatusForbidden, CodeForbidden, err.Error())
+	case errors.Is(err, domain.ErrUnauthorized):
+		p = New(http.StatusUnauthorized, CodeUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrDeviceRevoked):
+		p = New(http.StatusUnauthorized, CodeDeviceRevoked, err.Error())
+	case errors.Is(err, domain.ErrAccountDeleted):
+		p = New(http.StatusGone, CodeAccountDeleted, err.Error())
+	case errors.Is(err, domain.ErrAlreadyExists):
+		p = New(http.StatusConflict, CodeAlreadyExists, err.Error())
+	default:
+		if me, ok := domain.IsMutationError(err); ok && me.IsRetryable() {
+			p = New(http.StatusServiceUnavailable, CodeRetryable, err.Error())
+		} else {
+			// never leak internal error text to clients
+			p = New(http.StatusInternalServerError, CodeInternal, "internal error")
+		}
+	}
+
+	if me, ok := domain.IsMutationError(err); ok {
+		p.Retryable = me.IsRetryable()
+		p.Conflict = me.IsConflict()
+	}
+
+	return p
+}
+
+func (p *Problem) WithMutationID(mutationID string) *Problem {
+	p.MutationID = mutationID
+	return p
+}
+
+func (p *Problem) Write(w http.ResponseWriter) {
+	if p.MutationID != "" {
+		w.Header().Set("X-MUTATION-ID", p.MutationID)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
@@ -1,47 +1,445 @@
 package http
 
 import (
+	"Offline-First/internal/auth"
 	"Offline-First/internal/http/handler"
 	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/http/problem"
 	"net/http"
+	"strings"
 )
 
-func NewRouter(itemHandler *handler.ItemHandler) http.Handler {
+// Handlers groups every HTTP handler wired into the router.
+type Handlers struct {
+	Items       *handler.ItemHandler
+	Tags        *handler.TagHandler
+	Collections *handler.CollectionHandler
+	Links       *handler.LinkHandler
+	Sync        *handler.SyncHandler
+	Uploads     *handler.UploadHandler
+	Attachments *handler.AttachmentHandler
+	Shares      *handler.ShareHandler
+	Workspaces  *handler.WorkspaceHandler
+	Comments    *handler.CommentHandler
+	Devices     *handler.DeviceHandler
+	APIKeys     *handler.APIKeyHandler
+	Account     *handler.AccountHandler
+	Audit       *handler.AuditHandler
+}
+
+func NewRouter(h Handlers) http.Handler {
 	mux := http.NewServeMux()
 
+	// handle registers a route behind its scopes: the read scope for
+	// GET / HEAD, the write scope for every other method.
+	handle := func(pattern, read, write string, fn http.HandlerFunc) {
+		mux.Handle(pattern, middleware.Scoped(read, write, fn))
+	}
+
 	// /items (create, list)
-	mux.HandleFunc("/items", func(w http.ResponseWriter, r *http.Request) {
+	handle("/items", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPost:
-			middleware.MutationMiddleware(http.HandlerFunc(itemHandler.Create)).ServeHTTP(w, r)
+			middleware.MutationMiddleware(http.HandlerFunc(h.Items.Create)).ServeHTTP(w, r)
 		case http.MethodGet:
-			itemHandler.List(w, r)
+			h.Items.List(w, r)
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			problem.MethodNotAllowed().Write(w)
 		}
 	})
 
-	// /items/{id} (update, delete)
-	mux.HandleFunc("/items/", func(w http.ResponseWriter, r *http.Request) {
+	// /items/{id} (get, update, delete) and /items/{id}/{sub}/{sub_id}
+	handle("/items/", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		id, sub, subID := splitPath("/items/", r.URL.Path)
+
+		// /items/search (full-text search)
+		if id == "search" && sub == "" {
+			if r.Method != http.MethodGet {
+				problem.MethodNotAllowed().Write(w)
+				return
+			}
+			h.Items.Search(w, r)
+			return
+		}
+
+		r.SetPathValue("id", id)
+		r.SetPathValue("sub_id", subID)
+
+		switch sub {
+		case "":
+			switch r.Method {
+			case http.MethodGet:
+				h.Items.Get(w, r)
+			case http.MethodPut:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Items.Update)).ServeHTTP(w, r)
+			case http.MethodDelete:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Items.Delete)).ServeHTTP(w, r)
+			default:
+				problem.MethodNotAllowed().Write(w)
+
+			}
+
+		// /items/{id}/purge (delete forever)
+		case "purge":
+			if r.Method != http.MethodDelete {
+				problem.MethodNotAllowed().Write(w)
+				return
+			}
+			middleware.MutationMiddleware(http.HandlerFunc(h.Items.Purge)).ServeHTTP(w, r)
+
+		// /items/{id}/position (reorder)
+		case "position":
+			if r.Method != http.MethodPut {
+				problem.MethodNotAllowed().Write(w)
+				return
+			}
+			middleware.MutationMiddleware(http.HandlerFunc(h.Items.Reorder)).ServeHTTP(w, r)
+
+		// /items/{id}/tags/{tag_id} (assign, unassign)
+		case "tags":
+			switch r.Method {
+			case http.MethodPut:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Tags.Assign)).ServeHTTP(w, r)
+			case http.MethodDelete:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Tags.Unassign)).ServeHTTP(w, r)
+			default:
+				problem.MethodNotAllowed().Write(w)
+			}
+
+		// /items/{id}/backlinks
+		case "backlinks":
+			if r.Method != http.MethodGet {
+				problem.MethodNotAllowed().Write(w)
+				return
+			}
+			h.Links.Backlinks(w, r)
+
+		// /items/{id}/attachments (attach, list) and /items/{id}/attachments/{attachment_id} (detach)
+		case "attachments":
+			switch {
+			case subID == "" && r.Method == http.MethodPost:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Attachments.Create)).ServeHTTP(w, r)
+			case subID == "" && r.Method == http.MethodGet:
+				h.Attachments.List(w, r)
+			case subID != "" && r.Method == http.MethodDelete:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Attachments.Delete)).ServeHTTP(w, r)
+			default:
+				problem.MethodNotAllowed().Write(w)
+			}
+
+		// /items/{id}/shares (list) and /items/{id}/shares/{user_id} (share, unshare)
+		case "shares":
+			switch {
+			case subID == "" && r.Method == http.MethodGet:
+				h.Shares.List(w, r)
+			case subID != "" && r.Method == http.MethodPut:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Shares.Share)).ServeHTTP(w, r)
+			case subID != "" && r.Method == http.MethodDelete:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Shares.Unshare)).ServeHTTP(w, r)
+			default:
+				problem.MethodNotAllowed().Write(w)
+			}
+
+		// /items/{id}/comments (create, list)
+		case "comments":
+			switch {
+			case subID == "" && r.Method == http.MethodPost:
+				middleware.MutationMiddleware(http.HandlerFunc(h.Comments.Create)).ServeHTTP(w, r)
+			case subID == "" && r.Method == http.MethodGet:
+				h.Comments.List(w, r)
+			default:
+				problem.MethodNotAllowed().Write(w)
+			}
+
+		default:
+			problem.NotFound("route not found").Write(w)
+		}
+	})
+
+	// /comments/{id} (edit, delete)
+	handle("/comments/", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodPut:
-			middleware.MutationMiddleware(http.HandlerFunc(itemHandler.Update)).ServeHTTP(w, r)
+			middleware.MutationMiddleware(http.HandlerFunc(h.Comments.Update)).ServeHTTP(w, r)
 		case http.MethodDelete:
-			middleware.MutationMiddleware(http.HandlerFunc(itemHandler.Delete)).ServeHTTP(w, r)
+			middleware.MutationMiddleware(http.HandlerFunc(h.Comments.Delete)).ServeHTTP(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /graph (link graph of the caller's vault)
+	handle("/graph", auth.ScopeItemsRead, auth.ScopeItemsRead, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Links.Graph(w, r)
+	})
+
+	// /tags (create, list)
+	handle("/tags", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Tags.Create)).ServeHTTP(w, r)
+		case http.MethodGet:
+			h.Tags.List(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /tags/{id} (rename, delete)
+	handle("/tags/", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Tags.Rename)).ServeHTTP(w, r)
+		case http.MethodDelete:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Tags.Delete)).ServeHTTP(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /collections (create, list)
+	handle("/collections", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Collections.Create)).ServeHTTP(w, r)
+		case http.MethodGet:
+			h.Collections.List(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /collections/{id} (rename / move, delete)
+	handle("/collections/", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPut:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Collections.Update)).ServeHTTP(w, r)
+		case http.MethodDelete:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Collections.Delete)).ServeHTTP(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /uploads (start a resumable upload)
+	handle("/uploads", auth.ScopeItemsWrite, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Uploads.Start(w, r)
+	})
+
+	// /uploads/{id} (status, append chunk)
+	handle("/uploads/", auth.ScopeItemsWrite, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			h.Uploads.Status(w, r)
+		case http.MethodPatch:
+			h.Uploads.Append(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /blobs/{sha256} (download)
+	handle("/blobs/", auth.ScopeItemsRead, auth.ScopeItemsRead, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Uploads.Download(w, r)
+	})
+
+	// /workspaces (create, list)
+	handle("/workspaces", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Workspaces.Create)).ServeHTTP(w, r)
+		case http.MethodGet:
+			h.Workspaces.List(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /workspaces/{id} (rename) and /workspaces/{id}/members/{user_id}
+	handle("/workspaces/", auth.ScopeItemsRead, auth.ScopeItemsWrite, func(w http.ResponseWriter, r *http.Request) {
+		id, sub, subID := splitPath("/workspaces/", r.URL.Path)
+		r.SetPathValue("id", id)
+		r.SetPathValue("sub_id", subID)
+
+		switch {
+		case sub == "" && r.Method == http.MethodPut:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Workspaces.Rename)).ServeHTTP(w, r)
+		case sub == "members" && subID == "" && r.Method == http.MethodGet:
+			h.Workspaces.ListMembers(w, r)
+		case sub == "members" && subID != "" && r.Method == http.MethodPut:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Workspaces.SetMember)).ServeHTTP(w, r)
+		case sub == "members" && subID != "" && r.Method == http.MethodDelete:
+			middleware.MutationMiddleware(http.HandlerFunc(h.Workspaces.RemoveMember)).ServeHTTP(w, r)
+		case sub == "" || sub == "members":
+			problem.MethodNotAllowed().Write(w)
 		default:
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			problem.NotFound("route not found").Write(w)
+		}
+	})
 
+	// /devices (register, list)
+	handle("/devices", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.Devices.Register(w, r)
+		case http.MethodGet:
+			h.Devices.List(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /devices/{id} (revoke)
+	handle("/devices/", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Devices.Revoke(w, r)
+	})
+
+	// /api-keys (create, list)
+	handle("/api-keys", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			h.APIKeys.Create(w, r)
+		case http.MethodGet:
+			h.APIKeys.List(w, r)
+		default:
+			problem.MethodNotAllowed().Write(w)
+		}
+	})
+
+	// /api-keys/{id} (revoke)
+	handle("/api-keys/", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.APIKeys.Revoke(w, r)
+	})
+
+	// /account (erase the account)
+	handle("/account", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Account.Erase(w, r)
+	})
+
+	// /account/usage (storage used against quotas)
+	handle("/account/usage", auth.ScopeItemsRead, auth.ScopeItemsRead, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
 		}
+		h.Account.Usage(w, r)
+	})
+
+	// /account/export (request a data export)
+	handle("/account/export", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Account.RequestExport(w, r)
+	})
+
+	// /account/export/{id} (status) and /account/export/{id}/download
+	handle("/account/export/", auth.ScopeAccountManage, auth.ScopeAccountManage, func(w http.ResponseWriter, r *http.Request) {
+		id, sub, _ := splitPath("/account/export/", r.URL.Path)
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+
+		r.SetPathValue("id", id)
+
+		switch sub {
+		case "":
+			h.Account.ExportStatus(w, r)
+		case "download":
+			h.Account.DownloadExport(w, r)
+		default:
+			problem.NotFound("not found").Write(w)
+		}
+	})
+
+	// /admin/audit (audit log, admin only)
+	handle("/admin/audit", auth.ScopeAdmin, auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Audit.Query(w, r)
+	})
+
+	// /admin/audit/export (NDJSON)
+	handle("/admin/audit/export", auth.ScopeAdmin, auth.ScopeAdmin, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Audit.Export(w, r)
 	})
 
 	// /changes (sync API)
-	mux.HandleFunc("/changes", func(w http.ResponseWriter, r *http.Request) {
+	handle("/changes", auth.ScopeChangesRead, auth.ScopeChangesRead, func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+			problem.MethodNotAllowed().Write(w)
+			return
+		}
+		h.Sync.GetChanges(w, r)
+	})
+
+	// anything else gets the problem envelope, not net/http's plain-text 404
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		problem.NotFound("not found").Write(w)
+	})
+
+	return mux
+}
+
+// WithPublicRoutes serves the endpoints that need no access token and
+// hands every other request to secured.
+func WithPublicRoutes(h Handlers, secured http.Handler) http.Handler {
+	mux := http.NewServeMux()
+
+	// /auth/refresh (rotate a device refresh token)
+	mux.HandleFunc("/auth/refresh", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			problem.MethodNotAllowed().Write(w)
 			return
 		}
-		itemHandler.GetChanges(w, r)
+		h.Devices.Refresh(w, r)
 	})
 
+	mux.Handle("/", secured)
 	return mux
 }
+
+// splitPath splits {prefix}{id}/{sub}/{sub_id}; missing parts are "".
+func splitPath(prefix, path string) (id, sub, subID string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, prefix), "/", 3)
+	id = parts[0]
+	if len(parts) > 1 {
+		sub = parts[1]
+	}
+	if len(parts) > 2 {
+		subID = parts[2]
+	}
+	return id, sub, subID
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"Offline-First/internal/auth"
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// apiKeyStoreStub serves a single stored key.
+type apiKeyStoreStub struct {
+	key *domain.APIKey
+}
+
+func (s *apiKeyStoreStub) LookupAPIKey(_ context.Context, prefix string) (*domain.APIKey, error) {
+	if s.key == nil || s.key.Prefix != prefix {
+		return nil, domain.ErrNotFound
+	}
+	return s.key, nil
+}
+
+func (s *apiKeyStoreStub) TouchAPIKey(context.Context, string) error { return nil }
+
+type erasureStoreStub struct{}
+
+func (erasureStoreStub) AccountErased(context.Context, string) (bool, error) { return false, nil }
+
+// A key stored without scopes must not reach account:manage routes.
+func TestUnscopedAPIKeyCannotManageAccount(t *testing.T) {
+	key, prefix, hash, err := auth.NewAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &apiKeyStoreStub{key: &domain.APIKey{
+		ID:     uuid.NewString(),
+		UserID: uuid.NewString(),
+		Prefix: prefix,
+		Hash:   hash,
+	}}
+	h := middleware.Auth(auth.NewAPIKeyAuthenticator(store), erasureStoreStub{}, nil, NewRouter(Handlers{}))
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodGet, "/api-keys"},
+		{http.MethodPost, "/api-keys"},
+		{http.MethodDelete, "/account"},
+	} {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		r.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: status = %d, want 403", tc.method, tc.path, w.Code)
+			continue
+		}
+		if !strings.Contains(w.Body.String(), `"insufficient_scope"`) {
+			t.Errorf("%s %s: body = %s", tc.method, tc.path, w.Body)
+		}
+	}
+}
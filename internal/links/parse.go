@@ -0,0 +1,62 @@
+package links
+
+import (
+	"strings"
+)
+
+/*
+Parse extracts wiki-link targets from note content.
+
+Supported forms:
+
+	[[Target]]
+	[[Target|label]]      label is display-only
+	[[Target#heading]]    heading is ignored
+	[[<item uuid>]]       links by ID, survives renames
+
+Targets are trimmed and de-duplicated case-insensitively, preserving
+first-seen order so the stored rows are deterministic.
+*/
+func Parse(content string) []string {
+	var (
+		targets []string
+		seen    = map[string]bool{}
+	)
+
+	rest := content
+	for {
+		start := strings.Index(rest, "[[")
+		if start < 0 {
+			break
+		}
+		rest = rest[start+2:]
+
+		end := strings.Index(rest, "]]")
+		if end < 0 {
+			break
+		}
+		raw := rest[:end]
+		rest = rest[end+2:]
+
+		// a nested "[[" means the outer bracket was never closed
+		if i := strings.LastIndex(raw, "[["); i >= 0 {
+			raw = raw[i+2:]
+		}
+
+		target, _, _ := strings.Cut(raw, "|")
+		target, _, _ = strings.Cut(target, "#")
+		target = strings.TrimSpace(target)
+		if target == "" || strings.ContainsAny(target, "\n\r") {
+			continue
+		}
+
+		key := strings.ToLower(target)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, target)
+	}
+
+	return targets
+}
@@ -0,0 +1,119 @@
+package ordering
+
+import (
+	"errors"
+	"strings"
+)
+
+/*
+Keys are fractional indexes: base62 digits read as the fraction after
+a radix point, so "V" sorts between "A" and "a" and there is always
+room for another key between two neighbours. Keys compare bytewise
+(COLLATE "C" in Postgres) and never end in '0', which keeps every
+fraction to exactly one spelling.
+*/
+const Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const (
+	// MaxLength is the longest key the server accepts.
+	MaxLength = 64
+	// RebalanceLength triggers a rebalance of the key's list once exceeded.
+	RebalanceLength = 24
+)
+
+var ErrOutOfOrder = errors.New("ordering: lower key must sort before upper key")
+
+// Valid reports whether key is a well-formed, non-empty key within MaxLength.
+func Valid(key string) bool {
+	if key == "" || len(key) > MaxLength || key[len(key)-1] == '0' {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(Alphabet, key[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Between returns a key sorting strictly between lower and upper.
+
+An empty lower means the start of the list and an empty upper its end.
+The result is the shortest key the midpoint walk finds, so repeated
+inserts at the same spot grow keys by about one digit per six inserts.
+*/
+func Between(lower, upper string) (string, error) {
+	if upper != "" && lower >= upper {
+		return "", ErrOutOfOrder
+	}
+	return midpoint(lower, upper), nil
+}
+
+func midpoint(lower, upper string) string {
+	// keep the common prefix; missing digits of lower count as '0'
+	if upper != "" {
+		n := 0
+		for n < len(upper) && digitAt(lower, n, 0) == digit(upper[n]) {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(lower) {
+				rest = lower[n:]
+			}
+			return upper[:n] + midpoint(rest, upper[n:])
+		}
+	}
+
+	lo := digitAt(lower, 0, 0)
+	hi := digitAt(upper, 0, len(Alphabet))
+	if hi-lo > 1 {
+		return string(Alphabet[(lo+hi)/2])
+	}
+
+	// adjacent first digits: a shorter upper bound still fits above lower
+	if len(upper) > 1 {
+		return upper[:1]
+	}
+	rest := ""
+	if len(lower) > 1 {
+		rest = lower[1:]
+	}
+	return string(Alphabet[lo]) + midpoint(rest, "")
+}
+
+/*
+Spread returns n evenly spaced, ascending keys of the shortest width
+that fits them. It is used to rebalance a list whose keys grew long.
+*/
+func Spread(n int) []string {
+	width, space := 1, uint64(len(Alphabet))
+	for space <= uint64(n) {
+		width++
+		space *= uint64(len(Alphabet))
+	}
+
+	keys := make([]string, n)
+	buf := make([]byte, width)
+	for i := range keys {
+		v := uint64(i+1) * space / uint64(n+1)
+		for j := width - 1; j >= 0; j-- {
+			buf[j] = Alphabet[v%uint64(len(Alphabet))]
+			v /= uint64(len(Alphabet))
+		}
+		keys[i] = strings.TrimRight(string(buf), "0")
+	}
+	return keys
+}
+
+func digit(c byte) int {
+	return strings.IndexByte(Alphabet, c)
+}
+
+func digitAt(key string, i int, missing int) int {
+	if i >= len(key) {
+		return missing
+	}
+	return digit(key[i])
+}
@@ -0,0 +1,18 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.APIKey, error)
+	// Revoke is idempotent; revoking an already revoked key returns it unchanged.
+	Revoke(ctx context.Context, userID string, id string) (*domain.APIKey, error)
+
+	// LookupAPIKey returns a key by prefix, revoked keys included.
+	LookupAPIKey(ctx context.Context, prefix string) (*domain.APIKey, error)
+	// TouchAPIKey updates last_used_at at most once a minute.
+	TouchAPIKey(ctx context.Context, id string) error
+}
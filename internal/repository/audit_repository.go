@@ -0,0 +1,28 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"time"
+)
+
+// AuditQuery filters the audit log; zero fields match everything.
+type AuditQuery struct {
+	UserID   string
+	Category string
+	EntityID string
+	Since    time.Time
+	Until    time.Time
+
+	// BeforeID continues a newest-first page (Query only).
+	BeforeID int64
+	Limit    int
+}
+
+type AuditRepository interface {
+	Append(ctx context.Context, events ...*domain.AuditEvent) error
+	// Query returns up to q.Limit matching events, newest first.
+	Query(ctx context.Context, q AuditQuery) ([]*domain.AuditEvent, error)
+	// Export streams every matching event to fn, oldest first.
+	Export(ctx context.Context, q AuditQuery, fn func(*domain.AuditEvent) error) error
+}
@@ -0,0 +1,31 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"time"
+)
+
+type BlobRepository interface {
+	GetBlob(ctx context.Context, userID string, sha256 string) (*domain.Blob, error)
+
+	CreateUpload(ctx context.Context, upload *domain.Upload) (*domain.Upload, error)
+	GetUpload(ctx context.Context, userID string, id string) (*domain.Upload, error)
+	// AdvanceUpload moves received from `from` to `to`; it fails with a
+	// conflict if another request advanced the upload in the meantime.
+	AdvanceUpload(ctx context.Context, userID string, id string, from int64, to int64) (*domain.Upload, error)
+	// CompleteUpload registers the blob and removes the session atomically.
+	CompleteUpload(ctx context.Context, upload *domain.Upload) (*domain.Blob, error)
+	DeleteUpload(ctx context.Context, userID string, id string) error
+
+	DeleteOrphanBlobs(ctx context.Context, cutoff time.Time) ([]*domain.Blob, error)
+	DeleteExpiredUploads(ctx context.Context, cutoff time.Time) ([]string, error)
+}
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, a *domain.Attachment, mutationID string) (*domain.Attachment, error)
+	SoftDelete(ctx context.Context, id string, itemID string, userID string, version int, mutationID string) (*domain.Attachment, error)
+	ListByItem(ctx context.Context, userID string, itemID string) ([]*domain.Attachment, error)
+
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Attachment, int, error)
+}
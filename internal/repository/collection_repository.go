@@ -0,0 +1,16 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type CollectionRepository interface {
+	Create(ctx context.Context, c *domain.Collection, mutationID string) (*domain.Collection, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Collection, error)
+	// Update renames and/or moves; c.Version is the client's base version.
+	Update(ctx context.Context, c *domain.Collection, mutationID string) (*domain.Collection, error)
+	SoftDelete(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Collection, error)
+
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Collection, int, error)
+}
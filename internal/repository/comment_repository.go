@@ -0,0 +1,18 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, c *domain.Comment, mutationID string) (*domain.Comment, error)
+	// Update edits the body; only the author may edit. c.Version is the base version.
+	Update(ctx context.Context, c *domain.Comment, mutationID string) (*domain.Comment, error)
+	// SoftDelete is allowed for the author and the item's owner.
+	SoftDelete(ctx context.Context, id string, callerID string, version int, mutationID string) (*domain.Comment, error)
+	ListByItem(ctx context.Context, callerID string, itemID string) ([]*domain.Comment, error)
+
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Comment, int, error)
+	GetWorkspaceChanges(ctx context.Context, workspaceID string, sinceVersion int) ([]*domain.Comment, int, error)
+}
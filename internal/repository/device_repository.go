@@ -0,0 +1,23 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type DeviceRepository interface {
+	// Register stores a new device together with its first refresh token.
+	Register(ctx context.Context, d *domain.Device, token *domain.RefreshToken) (*domain.Device, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Device, error)
+	// Revoke is idempotent; revoking an already revoked device returns it unchanged.
+	Revoke(ctx context.Context, userID string, deviceID string) (*domain.Device, error)
+	DeviceActive(ctx context.Context, userID string, deviceID string) (bool, error)
+
+	/*
+		Rotate exchanges the refresh token tokenID (whose secret hashes to
+		hash) for next. Presenting a token that was already rotated is
+		treated as theft: the device is revoked and ErrDeviceRevoked is
+		returned. Unknown, mismatched or expired tokens are ErrUnauthorized.
+	*/
+	Rotate(ctx context.Context, tokenID string, hash string, next *domain.RefreshToken) (*domain.Device, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type ErasureRepository interface {
+	// Erase hard-deletes every row of userID and records the proof of
+	// erasure, in one transaction.
+	Erase(ctx context.Context, userID string) (*domain.Erasure, error)
+	// AccountErased reports whether userID has a proof of erasure.
+	AccountErased(ctx context.Context, userID string) (bool, error)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"time"
+)
+
+type ExportJobRepository interface {
+	// Create returns the user's pending or running job instead when there is one.
+	Create(ctx context.Context, job *domain.ExportJob) (*domain.ExportJob, error)
+	Get(ctx context.Context, userID string, id string) (*domain.ExportJob, error)
+	// Claim marks the oldest pending job running and returns it, or
+	// ErrNotFound when there is nothing to do. Jobs left running longer
+	// than staleAfter (a crashed worker) are claimed again.
+	Claim(ctx context.Context, staleAfter time.Duration) (*domain.ExportJob, error)
+	// Complete fails with ErrNotFound when the job was deleted meanwhile
+	// (its account was erased).
+	Complete(ctx context.Context, id string, size int64, expiresAt time.Time) error
+	Fail(ctx context.Context, id string, reason string) error
+	// DeleteExpired removes completed jobs past their expiry and returns their IDs.
+	DeleteExpired(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type MutationLogRepository interface {
+	// ListByUser returns the mutations userID applied, oldest first.
+	ListByUser(ctx context.Context, userID string) ([]*domain.MutationLogEntry, error)
+	// ListByEntities returns every mutation applied to the given entities, oldest first.
+	ListByEntities(ctx context.Context, entityType string, ids []string) ([]*domain.MutationLogEntry, error)
+}
@@ -3,13 +3,86 @@ package repository
 import (
 	domain "Offline-First/internal/domain/model"
 	"context"
+	"time"
 )
 
+// Sort fields accepted by ListByUser.
+const (
+	SortUpdatedAt = "updated_at"
+	SortCreatedAt = "created_at"
+	SortTitle     = "title"
+	SortVersion   = "version"
+	SortPosition  = "position"
+)
+
+// CollectionRoot selects the top level of a workspace in ItemListOptions.CollectionID.
+const CollectionRoot = "root"
+
+// ItemListOptions narrows and pages ListByUser.
+type ItemListOptions struct {
+	WorkspaceID    string // only items of this workspace (empty = every visible item)
+	CollectionID   string // only items of this collection, or CollectionRoot
+	Type           string
+	UpdatedSince   *time.Time
+	IncludeDeleted bool
+
+	MetadataHas    string            // only items whose metadata contains this key
+	MetadataEquals map[string]string // metadata[key] (as text) must equal value
+
+	SortField string // one of Sort*; defaults to updated_at
+	SortDesc  bool
+
+	// Limit is the page size. Cursor is the opaque value returned
+	// with the previous page; it is only valid for the same sort.
+	Limit  int
+	Cursor string
+}
+
+/*
+ItemMove places an item in its list (its collection, or the workspace
+top level). Exactly one of Position, AfterID or BeforeID is set: either
+the client's own key, or a neighbour the server places the item
+directly after / before.
+*/
+type ItemMove struct {
+	ID       string
+	UserID   string
+	Position string
+	AfterID  string
+	BeforeID string
+	Version  int // base version of the moved item
+}
+
+// ItemSearchQuery drives full-text search over title and content.
+type ItemSearchQuery struct {
+	Query          string   // web-search syntax: words, "exact phrase", -exclude, or
+	Types          []string // restrict to these item types (empty = all)
+	IncludeDeleted bool
+	Limit          int
+}
+
 type ItemRepository interface {
 	Create(ctx context.Context, item *domain.Item, mutationID string) (*domain.Item, error)
-	ListByUser(ctx context.Context, userId string) ([]*domain.Item, error)
+	// GetByID returns the item even if soft-deleted (tombstone).
+	GetByID(ctx context.Context, userID string, id string) (*domain.Item, error)
+	// ListByUser returns one page and the cursor of the next page ("" on the last page).
+	ListByUser(ctx context.Context, userId string, opts ItemListOptions) ([]*domain.Item, string, error)
 	Update(ctx context.Context, item *domain.Item, mutationID string) (*domain.Item, error)
 	SoftDelete(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Item, error)
+	// Purge strips a deleted item down to a tombstone; live items are rejected.
+	Purge(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Item, error)
+	// Reorder changes only the item's position; siblings keep their keys
+	// unless the list has to be rebalanced.
+	Reorder(ctx context.Context, move ItemMove, mutationID string) (*domain.Item, error)
+	Search(ctx context.Context, userID string, q ItemSearchQuery) ([]*domain.SearchResult, error)
 
+	// LatestVersion is the committed version of a team workspace's
+	// counter, or of the global one when workspaceID is empty.
+	LatestVersion(ctx context.Context, workspaceID string) (int, error)
 	GetChanges(ctx context.Context, userId string, sinceVersion int) ([]*domain.Item, int, error)
+	// ListByCreator returns every item userID created, in any workspace,
+	// tombstones included, oldest first.
+	ListByCreator(ctx context.Context, userID string) ([]*domain.Item, error)
+	// GetWorkspaceChanges uses the workspace's own version counter.
+	GetWorkspaceChanges(ctx context.Context, workspaceID string, sinceVersion int) ([]*domain.Item, int, error)
 }
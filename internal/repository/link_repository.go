@@ -0,0 +1,12 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+// LinkRepository reads the [[link]] index maintained by ItemRepository.
+type LinkRepository interface {
+	Backlinks(ctx context.Context, userID string, itemID string) ([]*domain.Item, error)
+	Graph(ctx context.Context, userID string) (*domain.LinkGraph, error)
+}
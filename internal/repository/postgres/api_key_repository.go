@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+type APIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{db: db}
+}
+
+// scopes are read as JSON so scanning does not depend on driver array support
+const apiKeyColumns = `id, user_id, name, prefix, secret_hash, array_to_json(scopes), created_at, last_used_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (*domain.APIKey, error) {
+	k := &domain.APIKey{}
+	var scopes []byte
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.Hash, &scopes, &k.CreatedAt, &lastUsedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(scopes, &k.Scopes); err != nil {
+		return nil, err
+	}
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	return k, nil
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) (*domain.APIKey, error) {
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := scanAPIKey(tx.QueryRowContext(ctx, `
+		INSERT INTO api_keys (id, user_id, name, prefix, secret_hash, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, now())
+		ON CONFLICT (id) DO NOTHING
+		RETURNING `+apiKeyColumns,
+		key.ID, key.UserID, key.Name, key.Prefix, key.Hash, scopes,
+	))
+	if err == domain.ErrNotFound {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e := auditEvent(ctx, key.UserID, domain.AuditCredential, "api_key.create")
+	e.EntityType, e.EntityID = "api_key", created.ID
+	e.Detail = map[string]any{"prefix": created.Prefix, "scopes": scopes}
+	if err := appendAuditTx(ctx, tx, e); err != nil {
+		return nil, err
+	}
+
+	middleware.LogWithContext(ctx, "api key created", "api_key_id", created.ID, "prefix", created.Prefix)
+	return created, tx.Commit()
+}
+
+func (r *APIKeyRepository) ListByUser(ctx context.Context, userID string) ([]*domain.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*domain.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, userID string, id string) (*domain.APIKey, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the first revoke is audited
+	if n, _ := res.RowsAffected(); n == 1 {
+		e := auditEvent(ctx, userID, domain.AuditCredential, "api_key.revoke")
+		e.EntityType, e.EntityID = "api_key", id
+		if err := appendAuditTx(ctx, tx, e); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := scanAPIKey(tx.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	middleware.LogWithContext(ctx, "api key revoked", "api_key_id", id)
+	return key, tx.Commit()
+}
+
+func (r *APIKeyRepository) LookupAPIKey(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	return scanAPIKey(r.db.QueryRowContext(ctx, `
+		SELECT `+apiKeyColumns+` FROM api_keys WHERE prefix = $1
+	`, prefix))
+}
+
+func (r *APIKeyRepository) TouchAPIKey(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, id)
+	return err
+}
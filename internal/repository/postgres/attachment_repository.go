@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// attachments are always read joined with their blob for size / content type
+const attachmentSelect = `
+	SELECT a.id, a.user_id, a.item_id, a.blob_sha256, a.filename,
+		COALESCE(b.content_type, ''), COALESCE(b.size, 0),
+		a.version, a.deleted, a.created_at, a.updated_at
+	FROM attachments a
+	LEFT JOIN blobs b ON b.user_id = a.user_id AND b.sha256 = a.blob_sha256
+`
+
+func scanAttachment(row rowScanner) (*domain.Attachment, error) {
+	a := &domain.Attachment{}
+	err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.ItemID,
+		&a.SHA256,
+		&a.Filename,
+		&a.ContentType,
+		&a.Size,
+		&a.Version,
+		&a.Deleted,
+		&a.CreatedAt,
+		&a.UpdatedAt,
+	)
+	return a, err
+}
+
+func (r *AttachmentRepository) getTx(ctx context.Context, tx *sql.Tx, userID, id string) (*domain.Attachment, error) {
+	a, err := scanAttachment(tx.QueryRowContext(ctx, attachmentSelect+`
+		WHERE a.id = $1 AND a.user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return a, err
+}
+
+func (r *AttachmentRepository) Create(ctx context.Context, a *domain.Attachment, mutationID string) (*domain.Attachment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (attachment create)", "attachment_id", a.ID, "applied_version", applied)
+
+		existing, err := r.getTx(ctx, tx, a.UserID, a.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Version = applied
+		return existing, nil
+	}
+
+	// 2️⃣ Ensure attachment does not already exist
+	_, err = r.getTx(ctx, tx, a.UserID, a.ID)
+	if err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	// 3️⃣ Item must be live; blob must exist (locked against GC)
+	var itemDeleted bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT deleted FROM items WHERE id = $1 AND workspace_id = $2 AND personal
+	`, a.ItemID, a.UserID).Scan(&itemDeleted)
+	if err == sql.ErrNoRows || (err == nil && itemDeleted) {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var blobExists bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT true FROM blobs WHERE user_id = $1 AND sha256 = $2 FOR SHARE
+	`, a.UserID, a.SHA256).Scan(&blobExists)
+	if err == sql.ErrNoRows {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "sha256",
+			Message: "blob not uploaded",
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Allocate global version
+	version, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Insert
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO attachments (id, user_id, item_id, blob_sha256, filename, version, deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, false, now(), now())
+	`, a.ID, a.UserID, a.ItemID, a.SHA256, a.Filename, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       a.UserID,
+		EntityType:   "attachment",
+		EntityID:     a.ID,
+		MutationType: "create",
+		Version:      version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTx(ctx, tx, a.UserID, a.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *AttachmentRepository) SoftDelete(ctx context.Context, id string, itemID string, userID string, version int, mutationID string) (*domain.Attachment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (attachment delete)", "attachment_id", id, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state
+	current, err := r.getTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.ItemID != itemID {
+		return nil, domain.ErrNotFound
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (attachment delete)",
+			"attachment_id", id,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone (the blob is left to the garbage collector)
+	_, err = tx.ExecContext(ctx, `
+		UPDATE attachments
+		SET deleted = true, version = $1, updated_at = now()
+		WHERE id = $2 AND user_id = $3
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "attachment",
+		EntityID:     id,
+		MutationType: "delete",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := r.getTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (r *AttachmentRepository) ListByItem(ctx context.Context, userID string, itemID string) ([]*domain.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx, attachmentSelect+`
+		WHERE a.user_id = $1 AND a.item_id = $2 AND a.deleted = false
+		ORDER BY a.created_at ASC, a.id ASC
+	`, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []*domain.Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+func (r *AttachmentRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Attachment, int, error) {
+	rows, err := r.db.QueryContext(ctx, attachmentSelect+`
+		WHERE a.user_id = $1 AND a.version > $2
+		ORDER BY a.version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		attachments   []*domain.Attachment
+		latestVersion = sinceVersion
+	)
+
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		latestVersion = max(latestVersion, a.Version)
+		attachments = append(attachments, a)
+	}
+	return attachments, latestVersion, rows.Err()
+}
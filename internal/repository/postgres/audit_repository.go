@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+const auditColumns = `id, occurred_at, category, action, outcome,
+	user_id, device_id, api_key_id, remote_addr,
+	entity_type, entity_id, mutation_id, version, detail`
+
+func scanAuditEvent(row rowScanner) (*domain.AuditEvent, error) {
+	e := &domain.AuditEvent{}
+	var (
+		userID, deviceID, apiKeyID, remoteAddr sql.NullString
+		entityType, entityID, mutationID       sql.NullString
+		version                                sql.NullInt64
+		detail                                 []byte
+	)
+	err := row.Scan(
+		&e.ID, &e.OccurredAt, &e.Category, &e.Action, &e.Outcome,
+		&userID, &deviceID, &apiKeyID, &remoteAddr,
+		&entityType, &entityID, &mutationID, &version, &detail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	e.UserID, e.DeviceID, e.APIKeyID, e.RemoteAddr = userID.String, deviceID.String, apiKeyID.String, remoteAddr.String
+	e.EntityType, e.EntityID, e.MutationID = entityType.String, entityID.String, mutationID.String
+	e.Version = int(version.Int64)
+	if err := json.Unmarshal(detail, &e.Detail); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// nullIfEmpty maps "" (and a zero version) to SQL NULL.
+func nullIfEmpty[T comparable](v T) any {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return v
+}
+
+func appendAuditTx(ctx context.Context, q execer, e *domain.AuditEvent) error {
+	detail, err := json.Marshal(e.Detail)
+	if err != nil {
+		return err
+	}
+	if e.Detail == nil {
+		detail = []byte(`{}`)
+	}
+
+	occurredAt := any(nil)
+	if !e.OccurredAt.IsZero() {
+		occurredAt = e.OccurredAt.UTC()
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO audit_log (
+			occurred_at, category, action, outcome,
+			user_id, device_id, api_key_id, remote_addr,
+			entity_type, entity_id, mutation_id, version, detail
+		) VALUES (COALESCE($1::timestamp, now()), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13::jsonb)
+	`,
+		occurredAt, e.Category, e.Action, e.Outcome,
+		nullIfEmpty(e.UserID), nullIfEmpty(e.DeviceID), nullIfEmpty(e.APIKeyID), nullIfEmpty(e.RemoteAddr),
+		nullIfEmpty(e.EntityType), nullIfEmpty(e.EntityID), nullIfEmpty(e.MutationID), nullIfEmpty(e.Version),
+		string(detail),
+	)
+	return err
+}
+
+/*
+auditEvent starts a successful event by userID, adding the device or
+API key the request in ctx authenticated with. Requests without a
+principal (token refresh) carry only the user.
+*/
+func auditEvent(ctx context.Context, userID string, category string, action string) *domain.AuditEvent {
+	e := &domain.AuditEvent{
+		Category: category,
+		Action:   action,
+		Outcome:  domain.AuditSuccess,
+		UserID:   userID,
+	}
+	if p, ok := middleware.PrincipalFromContext(ctx); ok && p.UserID == userID {
+		e.DeviceID, e.APIKeyID = p.DeviceID, p.APIKeyID
+	}
+	return e
+}
+
+/*
+Append writes events in one transaction (batches from the request
+path). Events of an erased user that happened before the erasure are
+dropped: they were queued while the erasure ran and would otherwise
+bring the user ID back into the log.
+*/
+func (r *AuditRepository) Append(ctx context.Context, events ...*domain.AuditEvent) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	hashes := []string{}
+	for _, e := range events {
+		if e.UserID != "" {
+			hashes = append(hashes, erasureHash(e.UserID))
+		}
+	}
+	erasedAt := map[string]time.Time{}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT subject_hash, erased_at FROM account_erasures WHERE subject_hash = ANY($1::text[])
+	`, hashes)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var (
+			hash string
+			at   time.Time
+		)
+		if err := rows.Scan(&hash, &at); err != nil {
+			rows.Close()
+			return err
+		}
+		erasedAt[hash] = at
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for _, e := range events {
+		if at, ok := erasedAt[erasureHash(e.UserID)]; ok && e.UserID != "" && !e.OccurredAt.After(at) {
+			continue
+		}
+		if err := appendAuditTx(ctx, tx, e); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// auditFilter renders the WHERE clause shared by Query and Export.
+func auditFilter(q repository.AuditQuery) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+
+	if q.UserID != "" {
+		add("user_id = ?", q.UserID)
+	}
+	if q.Category != "" {
+		add("category = ?", q.Category)
+	}
+	if q.EntityID != "" {
+		add("entity_id = ?", q.EntityID)
+	}
+	if !q.Since.IsZero() {
+		add("occurred_at >= ?", q.Since.UTC())
+	}
+	if !q.Until.IsZero() {
+		add("occurred_at < ?", q.Until.UTC())
+	}
+	if q.BeforeID > 0 {
+		add("id < ?", q.BeforeID)
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *AuditRepository) Query(ctx context.Context, q repository.AuditQuery) ([]*domain.AuditEvent, error) {
+	where, args := auditFilter(q)
+	args = append(args, q.Limit)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_log
+		`+where+`
+		ORDER BY id DESC
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*domain.AuditEvent{}
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (r *AuditRepository) Export(ctx context.Context, q repository.AuditQuery, fn func(*domain.AuditEvent) error) error {
+	q.BeforeID = 0
+	where, args := auditFilter(q)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+auditColumns+`
+		FROM audit_log
+		`+where+`
+		ORDER BY id ASC
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
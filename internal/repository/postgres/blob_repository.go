@@ -0,0 +1,222 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type BlobRepository struct {
+	db    *sql.DB
+	quota domain.Quota
+}
+
+// NewBlobRepository charges stored blobs to their user's attachment bytes, capped by quota.
+func NewBlobRepository(db *sql.DB, quota domain.Quota) *BlobRepository {
+	return &BlobRepository{db: db, quota: quota}
+}
+
+const uploadColumns = `id, user_id, sha256, size, received, content_type, created_at`
+
+func scanUpload(row rowScanner) (*domain.Upload, error) {
+	u := &domain.Upload{}
+	err := row.Scan(&u.ID, &u.UserID, &u.SHA256, &u.Size, &u.Received, &u.ContentType, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return u, err
+}
+
+func (r *BlobRepository) GetBlob(ctx context.Context, userID string, sha256 string) (*domain.Blob, error) {
+	b := &domain.Blob{}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id, sha256, size, content_type, created_at
+		FROM blobs
+		WHERE user_id = $1 AND sha256 = $2
+	`, userID, sha256).Scan(&b.UserID, &b.SHA256, &b.Size, &b.ContentType, &b.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return b, err
+}
+
+/*
+CreateUpload opens an upload session. A session whose blob would not
+fit the attachment quota is refused up front, so the client does not
+send bytes that CompleteUpload would reject.
+*/
+func (r *BlobRepository) CreateUpload(ctx context.Context, upload *domain.Upload) (*domain.Upload, error) {
+	if r.quota.AttachmentBytes > 0 {
+		var used int64
+		err := r.db.QueryRowContext(ctx, `
+			SELECT COALESCE((SELECT attachment_bytes FROM user_usage WHERE user_id = $1), 0)
+		`, upload.UserID).Scan(&used)
+		if err != nil {
+			return nil, err
+		}
+		if used+upload.Size > r.quota.AttachmentBytes {
+			return nil, &domain.QuotaExceededError{Resource: domain.QuotaAttachmentBytes, Limit: r.quota.AttachmentBytes}
+		}
+	}
+
+	return scanUpload(r.db.QueryRowContext(ctx, `
+		INSERT INTO uploads (id, user_id, sha256, size, received, content_type, created_at)
+		VALUES ($1, $2, $3, $4, 0, $5, now())
+		RETURNING `+uploadColumns,
+		upload.ID, upload.UserID, upload.SHA256, upload.Size, upload.ContentType,
+	))
+}
+
+func (r *BlobRepository) GetUpload(ctx context.Context, userID string, id string) (*domain.Upload, error) {
+	return scanUpload(r.db.QueryRowContext(ctx, `
+		SELECT `+uploadColumns+`
+		FROM uploads
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+}
+
+func (r *BlobRepository) AdvanceUpload(ctx context.Context, userID string, id string, from int64, to int64) (*domain.Upload, error) {
+	upload, err := scanUpload(r.db.QueryRowContext(ctx, `
+		UPDATE uploads
+		SET received = $1
+		WHERE id = $2 AND user_id = $3 AND received = $4
+		RETURNING `+uploadColumns,
+		to, id, userID, from,
+	))
+	if err != domain.ErrNotFound {
+		return upload, err
+	}
+
+	// either gone, or someone else moved the offset
+	current, err := r.GetUpload(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return nil, domain.NewEntityConflictError(current)
+}
+
+func (r *BlobRepository) CompleteUpload(ctx context.Context, upload *domain.Upload) (*domain.Blob, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// an identical blob may have been completed by a parallel upload
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO blobs (user_id, sha256, size, content_type, created_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (user_id, sha256) DO NOTHING
+	`, upload.UserID, upload.SHA256, upload.Size, upload.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	// only a new blob takes up space
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		err = chargeUsageTx(ctx, tx, upload.UserID, r.quota, domain.Usage{AttachmentBytes: upload.Size})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM uploads WHERE id = $1 AND user_id = $2`, upload.ID, upload.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &domain.Blob{}
+	err = tx.QueryRowContext(ctx, `
+		SELECT user_id, sha256, size, content_type, created_at
+		FROM blobs
+		WHERE user_id = $1 AND sha256 = $2
+	`, upload.UserID, upload.SHA256).Scan(&b.UserID, &b.SHA256, &b.Size, &b.ContentType, &b.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, tx.Commit()
+}
+
+func (r *BlobRepository) DeleteUpload(ctx context.Context, userID string, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM uploads WHERE id = $1 AND user_id = $2`, id, userID)
+	return err
+}
+
+/*
+DeleteOrphanBlobs removes blobs no live attachment points at and
+releases their bytes from their users' usage.
+
+The NOT EXISTS check and the DELETE are one statement, and attaching
+locks the blob row (FOR SHARE), so a blob cannot be collected while an
+attachment to it is being created.
+*/
+func (r *BlobRepository) DeleteOrphanBlobs(ctx context.Context, cutoff time.Time) ([]*domain.Blob, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM blobs b
+		WHERE b.created_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM attachments a
+			WHERE a.user_id = b.user_id
+			AND a.blob_sha256 = b.sha256
+			AND a.deleted = false
+		)
+		RETURNING user_id, sha256, size, content_type, created_at
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var blobs []*domain.Blob
+	for rows.Next() {
+		b := &domain.Blob{}
+		if err := rows.Scan(&b.UserID, &b.SHA256, &b.Size, &b.ContentType, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for _, b := range blobs {
+		err := chargeUsageTx(ctx, tx, b.UserID, r.quota, domain.Usage{AttachmentBytes: -b.Size})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return blobs, tx.Commit()
+}
+
+func (r *BlobRepository) DeleteExpiredUploads(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		DELETE FROM uploads
+		WHERE created_at < $1
+		RETURNING id
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,430 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type CollectionRepository struct {
+	db *sql.DB
+}
+
+func NewCollectionRepository(db *sql.DB) *CollectionRepository {
+	return &CollectionRepository{db: db}
+}
+
+const collectionColumns = `id, user_id, parent_id, name, version, deleted, created_at, updated_at`
+
+func scanCollection(row rowScanner) (*domain.Collection, error) {
+	c := &domain.Collection{}
+	var parentID sql.NullString
+
+	err := row.Scan(
+		&c.ID,
+		&c.UserID,
+		&parentID,
+		&c.Name,
+		&c.Version,
+		&c.Deleted,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if parentID.Valid {
+		c.ParentID = &parentID.String
+	}
+	return c, err
+}
+
+func (r *CollectionRepository) getTx(ctx context.Context, tx *sql.Tx, userID, id string) (*domain.Collection, error) {
+	c, err := scanCollection(tx.QueryRowContext(ctx, `
+		SELECT `+collectionColumns+`
+		FROM collections
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return c, err
+}
+
+/*
+lockTreeTx serialises structural changes to one user's tree.
+
+Cycle detection walks ancestors, which is only sound if no other
+transaction can move a collection between the walk and the commit.
+*/
+func lockTreeTx(ctx context.Context, tx *sql.Tx, userID string) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('collections:' || $1))`, userID)
+	return err
+}
+
+// validateParentTx checks the new parent exists and reports whether
+// placing id under it would create a cycle.
+func (r *CollectionRepository) validateParentTx(ctx context.Context, tx *sql.Tx, userID, id string, parentID *string) (bool, error) {
+	if parentID == nil {
+		return false, nil
+	}
+
+	parent, err := r.getTx(ctx, tx, userID, *parentID)
+	if err == domain.ErrNotFound || (err == nil && parent.Deleted) {
+		return false, domain.NewValidationError(domain.FieldError{
+			Field:   "parent_id",
+			Message: "collection not found",
+		})
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var cycle bool
+	err = tx.QueryRowContext(ctx, `
+		WITH RECURSIVE ancestors(id, parent_id) AS (
+			SELECT id, parent_id FROM collections WHERE id = $1 AND user_id = $3
+			UNION
+			SELECT c.id, c.parent_id
+			FROM collections c
+			JOIN ancestors a ON c.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)
+	`, *parentID, id, userID).Scan(&cycle)
+
+	return cycle, err
+}
+
+func (r *CollectionRepository) Create(ctx context.Context, c *domain.Collection, mutationID string) (*domain.Collection, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (collection create)", "collection_id", c.ID, "applied_version", applied)
+
+		existing, err := r.getTx(ctx, tx, c.UserID, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Version = applied
+		return existing, nil
+	}
+
+	// 2️⃣ Ensure collection does not already exist
+	_, err = r.getTx(ctx, tx, c.UserID, c.ID)
+	if err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	// a brand-new collection cannot be anyone's ancestor yet
+	if err := lockTreeTx(ctx, tx, c.UserID); err != nil {
+		return nil, err
+	}
+	if _, err := r.validateParentTx(ctx, tx, c.UserID, c.ID, c.ParentID); err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Allocate global version
+	version, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Insert
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO collections (id, user_id, parent_id, name, version, deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, false, now(), now())
+	`, c.ID, c.UserID, nullableID(c.ParentID), c.Name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       c.UserID,
+		EntityType:   "collection",
+		EntityID:     c.ID,
+		MutationType: "create",
+		Version:      version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTx(ctx, tx, c.UserID, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *CollectionRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Collection, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+collectionColumns+`
+		FROM collections
+		WHERE user_id = $1 AND deleted = false
+		ORDER BY name ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*domain.Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+/*
+Update renames and/or moves a collection (and resurrects it if deleted).
+
+If the move would create a cycle, the collection is moved to the top
+level instead, the mutation is still applied and recorded, and a
+*domain.CycleConflictError carrying the resulting state is returned.
+*/
+func (r *CollectionRepository) Update(ctx context.Context, c *domain.Collection, mutationID string) (*domain.Collection, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (collection update)", "collection_id", c.ID, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, c.UserID, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Lock the tree, then load current state
+	if err := lockTreeTx(ctx, tx, c.UserID); err != nil {
+		return nil, err
+	}
+
+	current, err := r.getTx(ctx, tx, c.UserID, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != c.Version {
+		middleware.LogWithContext(ctx, "version conflict (collection update)",
+			"collection_id", c.ID,
+			"client_version", c.Version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Resolve the parent (nil keeps it, "" is the top level) and detect cycles
+	parentID := current.ParentID
+	if c.ParentID != nil {
+		parentID = c.ParentID
+		if *parentID == "" {
+			parentID = nil
+		}
+	}
+
+	cycle, err := r.validateParentTx(ctx, tx, c.UserID, c.ID, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if cycle {
+		middleware.LogWithContext(ctx, "cycle detected (collection move), reparenting to root",
+			"collection_id", c.ID,
+			"requested_parent", *parentID,
+		)
+		parentID = nil
+	}
+
+	// 4️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Apply
+	_, err = tx.ExecContext(ctx, `
+		UPDATE collections
+		SET name = $1, parent_id = $2, version = $3, deleted = false, updated_at = now()
+		WHERE id = $4 AND user_id = $5
+	`, c.Name, nullableID(parentID), newVersion, c.ID, c.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       c.UserID,
+		EntityType:   "collection",
+		EntityID:     c.ID,
+		MutationType: "update",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.getTx(ctx, tx, c.UserID, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if cycle {
+		return nil, &domain.CycleConflictError{Collection: updated}
+	}
+	return updated, nil
+}
+
+/*
+SoftDelete tombstones the collection and moves its direct children
+(collections and items) to the top level under the same version,
+so no live entity ever points at a deleted parent.
+*/
+func (r *CollectionRepository) SoftDelete(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Collection, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (collection delete)", "collection_id", id, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Lock the tree, then load current state
+	if err := lockTreeTx(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	current, err := r.getTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (collection delete)",
+			"collection_id", id,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone and move children to the top level
+	_, err = tx.ExecContext(ctx, `
+		UPDATE collections
+		SET deleted = true, version = $1, updated_at = now()
+		WHERE id = $2 AND user_id = $3
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE collections
+		SET parent_id = NULL, version = $1, updated_at = now()
+		WHERE parent_id = $2 AND user_id = $3
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE items
+		SET collection_id = NULL, version = $1, updated_at = now()
+		WHERE collection_id = $2 AND user_id = $3
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "collection",
+		EntityID:     id,
+		MutationType: "delete",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := r.getTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (r *CollectionRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Collection, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+collectionColumns+`
+		FROM collections
+		WHERE user_id = $1 AND version > $2
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		collections   []*domain.Collection
+		latestVersion = sinceVersion
+	)
+
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		latestVersion = max(latestVersion, c.Version)
+		collections = append(collections, c)
+	}
+	return collections, latestVersion, rows.Err()
+}
@@ -0,0 +1,378 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type CommentRepository struct {
+	db *sql.DB
+}
+
+func NewCommentRepository(db *sql.DB) *CommentRepository {
+	return &CommentRepository{db: db}
+}
+
+// commentColumns expects comments to be aliased as c.
+const commentColumns = `c.id, c.item_id, c.user_id, c.parent_id, c.body, c.version, c.deleted, c.created_at, c.updated_at`
+
+func scanComment(row rowScanner) (*domain.Comment, error) {
+	c := &domain.Comment{}
+	var parentID sql.NullString
+	err := row.Scan(&c.ID, &c.ItemID, &c.UserID, &parentID, &c.Body, &c.Version, &c.Deleted, &c.CreatedAt, &c.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if parentID.Valid {
+		c.ParentID = &parentID.String
+	}
+	return c, err
+}
+
+func (r *CommentRepository) getTx(ctx context.Context, tx *sql.Tx, id string) (*domain.Comment, error) {
+	return scanComment(tx.QueryRowContext(ctx, `
+		SELECT `+commentColumns+` FROM comments c WHERE c.id = $1
+	`, id))
+}
+
+func (r *CommentRepository) Create(ctx context.Context, c *domain.Comment, mutationID string) (*domain.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (comment create)", "comment_id", c.ID, "applied_version", applied)
+
+		existing, err := r.getTx(ctx, tx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Version = applied
+		return existing, nil
+	}
+
+	// 2️⃣ Ensure comment does not already exist
+	_, err = r.getTx(ctx, tx, c.ID)
+	if err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	// 3️⃣ Anyone who can read a live item may comment on it
+	item, _, err := accessibleItemTx(ctx, tx, c.UserID, c.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if item.Deleted {
+		return nil, domain.ErrNotFound
+	}
+
+	// replies stay within one item and cannot hang off a deleted comment
+	if c.ParentID != nil {
+		parent, err := r.getTx(ctx, tx, *c.ParentID)
+		if err != nil && err != domain.ErrNotFound {
+			return nil, err
+		}
+		if parent == nil || parent.ItemID != c.ItemID || parent.Deleted {
+			return nil, domain.NewValidationError(domain.FieldError{
+				Field:   "parent_id",
+				Message: "parent comment not found on this item",
+			})
+		}
+	}
+
+	// 4️⃣ Allocate the item's workspace version
+	version, err := nextWorkspaceVersion(ctx, tx, item.WorkspaceID, item.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Insert
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO comments (id, item_id, user_id, parent_id, body, version, deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, false, now(), now())
+	`, c.ID, c.ItemID, c.UserID, nullableID(c.ParentID), c.Body, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       c.UserID,
+		EntityType:   "comment",
+		EntityID:     c.ID,
+		MutationType: "create",
+		Version:      version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTx(ctx, tx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *CommentRepository) Update(ctx context.Context, c *domain.Comment, mutationID string) (*domain.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (comment update)", "comment_id", c.ID, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state; only the author may edit
+	current, item, _, err := r.loadAccessibleTx(ctx, tx, c.UserID, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current.UserID != c.UserID {
+		return nil, domain.ErrForbidden
+	}
+
+	if current.Version != c.Version {
+		middleware.LogWithContext(ctx, "version conflict (comment update)",
+			"comment_id", c.ID,
+			"client_version", c.Version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+	if current.Deleted {
+		return nil, domain.ErrNotFound
+	}
+
+	// 3️⃣ Allocate the item's workspace version
+	newVersion, err := nextWorkspaceVersion(ctx, tx, item.WorkspaceID, item.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Edit body
+	_, err = tx.ExecContext(ctx, `
+		UPDATE comments
+		SET body = $1, version = $2, updated_at = now()
+		WHERE id = $3
+	`, c.Body, newVersion, c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       c.UserID,
+		EntityType:   "comment",
+		EntityID:     c.ID,
+		MutationType: "update",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.getTx(ctx, tx, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, tx.Commit()
+}
+
+func (r *CommentRepository) SoftDelete(ctx context.Context, id string, callerID string, version int, mutationID string) (*domain.Comment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (comment delete)", "comment_id", id, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state; the author or the item's owner may delete
+	current, item, role, err := r.loadAccessibleTx(ctx, tx, callerID, id)
+	if err != nil {
+		return nil, err
+	}
+	if current.UserID != callerID && role != domain.RoleOwner {
+		return nil, domain.ErrForbidden
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (comment delete)",
+			"comment_id", id,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate the item's workspace version
+	newVersion, err := nextWorkspaceVersion(ctx, tx, item.WorkspaceID, item.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone; the body is cleared, replies keep their parent
+	_, err = tx.ExecContext(ctx, `
+		UPDATE comments
+		SET deleted = true, body = '', version = $1, updated_at = now()
+		WHERE id = $2
+	`, newVersion, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "comment",
+		EntityID:     id,
+		MutationType: "delete",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := r.getTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+// loadAccessibleTx loads a comment together with its item and the caller's role on it.
+func (r *CommentRepository) loadAccessibleTx(ctx context.Context, tx *sql.Tx, callerID, id string) (*domain.Comment, *domain.Item, string, error) {
+	current, err := r.getTx(ctx, tx, id)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	item, role, err := accessibleItemTx(ctx, tx, callerID, current.ItemID)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return current, item, role, nil
+}
+
+// ListByItem returns the whole thread, tombstones included, oldest first.
+func (r *CommentRepository) ListByItem(ctx context.Context, callerID string, itemID string) ([]*domain.Comment, error) {
+	if _, _, err := accessibleItemTx(ctx, r.db, callerID, itemID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		WHERE c.item_id = $1
+		ORDER BY c.created_at ASC, c.id ASC
+	`, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return collectComments(rows)
+}
+
+/*
+GetChanges mirrors ItemRepository.GetChanges: comments on items of the
+personal workspace, plus comments on items shared with the user that
+changed, or whose share was granted, after sinceVersion.
+*/
+func (r *CommentRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Comment, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN items i ON i.id = c.item_id
+		WHERE (i.personal AND i.workspace_id = $1 AND c.version > $2)
+		OR EXISTS (
+			SELECT 1 FROM item_shares s
+			WHERE s.item_id = c.item_id
+			AND s.user_id = $1
+			AND s.revoked = false
+			AND (c.version > $2 OR s.version > $2)
+		)
+		ORDER BY c.version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments, err := collectComments(rows)
+	return comments, latestCommentVersion(comments, sinceVersion), err
+}
+
+func (r *CommentRepository) GetWorkspaceChanges(ctx context.Context, workspaceID string, sinceVersion int) ([]*domain.Comment, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+commentColumns+`
+		FROM comments c
+		JOIN items i ON i.id = c.item_id
+		WHERE i.workspace_id = $1 AND NOT i.personal AND c.version > $2
+		ORDER BY c.version ASC
+	`, workspaceID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	comments, err := collectComments(rows)
+	return comments, latestCommentVersion(comments, sinceVersion), err
+}
+
+func collectComments(rows *sql.Rows) ([]*domain.Comment, error) {
+	comments := []*domain.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func latestCommentVersion(comments []*domain.Comment, sinceVersion int) int {
+	latest := sinceVersion
+	for _, c := range comments {
+		latest = max(latest, c.Version)
+	}
+	return latest
+}
@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/repository"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+/*
+listCursor is the keyset position of the last item of a page.
+
+It is handed to clients as opaque base64 and carries the sort it was
+produced for, so it cannot be replayed against a different ordering.
+*/
+type listCursor struct {
+	Field string `json:"f"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+// sortColumns maps sort fields to their column and keyset cast.
+var sortColumns = map[string]string{
+	repository.SortUpdatedAt: "timestamp",
+	repository.SortCreatedAt: "timestamp",
+	repository.SortTitle:     "text",
+	repository.SortVersion:   "bigint",
+	repository.SortPosition:  "text",
+}
+
+func encodeCursor(field string, desc bool, last *domain.Item) string {
+	c := listCursor{Field: field, Desc: desc, ID: last.ID}
+
+	switch field {
+	case repository.SortCreatedAt:
+		c.Value = last.CreatedAt.Format(time.RFC3339Nano)
+	case repository.SortTitle:
+		c.Value = last.Title
+	case repository.SortVersion:
+		c.Value = strconv.Itoa(last.Version)
+	case repository.SortPosition:
+		c.Value = last.Position
+	default:
+		c.Value = last.UpdatedAt.Format(time.RFC3339Nano)
+	}
+
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string, field string, desc bool) (*listCursor, error) {
+	invalid := domain.NewValidationError(domain.FieldError{
+		Field:   "cursor",
+		Message: "is invalid for this sort",
+	})
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var c listCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, invalid
+	}
+	if c.Field != field || c.Desc != desc || c.ID == "" {
+		return nil, invalid
+	}
+	return &c, nil
+}
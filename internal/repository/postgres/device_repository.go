@@ -0,0 +1,274 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"time"
+)
+
+type DeviceRepository struct {
+	db *sql.DB
+}
+
+func NewDeviceRepository(db *sql.DB) *DeviceRepository {
+	return &DeviceRepository{db: db}
+}
+
+const deviceColumns = `id, user_id, name, created_at, last_seen_at, revoked_at`
+
+func scanDevice(row rowScanner) (*domain.Device, error) {
+	d := &domain.Device{}
+	var revokedAt sql.NullTime
+	err := row.Scan(&d.ID, &d.UserID, &d.Name, &d.CreatedAt, &d.LastSeenAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if revokedAt.Valid {
+		d.RevokedAt = &revokedAt.Time
+	}
+	return d, err
+}
+
+func (r *DeviceRepository) getTx(ctx context.Context, q queryer, id string) (*domain.Device, error) {
+	return scanDevice(q.QueryRowContext(ctx, `
+		SELECT `+deviceColumns+` FROM devices WHERE id = $1
+	`, id))
+}
+
+func insertRefreshTokenTx(ctx context.Context, tx *sql.Tx, t *domain.RefreshToken) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (id, device_id, token_hash, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, now())
+	`, t.ID, t.DeviceID, t.Hash, t.ExpiresAt.UTC())
+	return err
+}
+
+func (r *DeviceRepository) Register(ctx context.Context, d *domain.Device, token *domain.RefreshToken) (*domain.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Device IDs are never reused, not even after a revoke
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO devices (id, user_id, name, created_at, last_seen_at)
+		VALUES ($1, $2, $3, now(), now())
+		ON CONFLICT (id) DO NOTHING
+	`, d.ID, d.UserID, d.Name)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, domain.ErrAlreadyExists
+	}
+
+	// 2️⃣ First refresh token
+	if err := insertRefreshTokenTx(ctx, tx, token); err != nil {
+		return nil, err
+	}
+
+	// 3️⃣ Audit
+	e := auditEvent(ctx, d.UserID, domain.AuditCredential, "device.register")
+	e.EntityType, e.EntityID = "device", d.ID
+	if err := appendAuditTx(ctx, tx, e); err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTx(ctx, tx, d.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *DeviceRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Device, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+deviceColumns+`
+		FROM devices
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devices := []*domain.Device{}
+	for rows.Next() {
+		d, err := scanDevice(rows)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, d)
+	}
+	return devices, rows.Err()
+}
+
+func (r *DeviceRepository) Revoke(ctx context.Context, userID string, deviceID string) (*domain.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE devices SET revoked_at = now()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, deviceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// only the first revoke is audited
+	if n, _ := res.RowsAffected(); n == 1 {
+		e := auditEvent(ctx, userID, domain.AuditCredential, "device.revoke")
+		e.EntityType, e.EntityID = "device", deviceID
+		if err := appendAuditTx(ctx, tx, e); err != nil {
+			return nil, err
+		}
+	}
+
+	d, err := r.getTx(ctx, tx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if d.UserID != userID {
+		return nil, domain.ErrNotFound
+	}
+
+	middleware.LogWithContext(ctx, "device revoked", "device_id", deviceID)
+	return d, tx.Commit()
+}
+
+// DeviceActive fails with ErrAccountDeleted once the device's account is erased.
+func (r *DeviceRepository) DeviceActive(ctx context.Context, userID string, deviceID string) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT revoked_at IS NULL FROM devices WHERE id = $1 AND user_id = $2
+	`, deviceID, userID).Scan(&active)
+	if err == sql.ErrNoRows {
+		erased, err := accountErased(ctx, r.db, userID)
+		if err != nil {
+			return false, err
+		}
+		if erased {
+			return false, domain.ErrAccountDeleted
+		}
+		return false, nil
+	}
+	return active, err
+}
+
+func (r *DeviceRepository) Rotate(ctx context.Context, tokenID string, hash string, next *domain.RefreshToken) (*domain.Device, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Lock the presented token so concurrent refreshes serialise
+	var (
+		deviceID, storedHash string
+		expiresAt            time.Time
+		usedAt               sql.NullTime
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT device_id, token_hash, expires_at, used_at
+		FROM refresh_tokens
+		WHERE id = $1
+		FOR UPDATE
+	`, tokenID).Scan(&deviceID, &storedHash, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		// tokens of an erased account are only kept as a hash
+		var erased bool
+		err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM erased_refresh_tokens WHERE id_hash = $1)
+		`, erasureHash(tokenID)).Scan(&erased)
+		if err != nil {
+			return nil, err
+		}
+		if erased {
+			return nil, domain.ErrAccountDeleted
+		}
+		return nil, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(storedHash), []byte(hash)) != 1 {
+		return nil, domain.ErrUnauthorized
+	}
+
+	device, err := r.getTx(ctx, tx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device.Revoked() {
+		return nil, domain.ErrDeviceRevoked
+	}
+
+	// 2️⃣ Reuse of a rotated token: someone else holds a copy, so the
+	// whole device session is revoked
+	if usedAt.Valid {
+		middleware.LogWithContext(ctx, "refresh token reuse detected",
+			"device_id", deviceID,
+			"token_id", tokenID,
+			"used_at", usedAt.Time,
+		)
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE devices SET revoked_at = now() WHERE id = $1
+		`, deviceID); err != nil {
+			return nil, err
+		}
+
+		e := auditEvent(ctx, device.UserID, domain.AuditCredential, "device.revoke")
+		e.DeviceID, e.EntityType, e.EntityID = deviceID, "device", deviceID
+		e.Detail = map[string]any{"reason": "refresh_token_reuse", "token_id": tokenID}
+		if err := appendAuditTx(ctx, tx, e); err != nil {
+			return nil, err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrDeviceRevoked
+	}
+
+	if !time.Now().Before(expiresAt) {
+		return nil, domain.ErrUnauthorized
+	}
+
+	// 3️⃣ Rotate
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE refresh_tokens SET used_at = now() WHERE id = $1
+	`, tokenID); err != nil {
+		return nil, err
+	}
+
+	next.DeviceID = deviceID
+	if err := insertRefreshTokenTx(ctx, tx, next); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE devices SET last_seen_at = now() WHERE id = $1
+	`, deviceID); err != nil {
+		return nil, err
+	}
+
+	e := auditEvent(ctx, device.UserID, domain.AuditAuth, "refresh")
+	e.DeviceID = deviceID
+	if err := appendAuditTx(ctx, tx, e); err != nil {
+		return nil, err
+	}
+
+	rotated, err := r.getTx(ctx, tx, deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return rotated, tx.Commit()
+}
@@ -0,0 +1,534 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ErasureRepository struct {
+	db *sql.DB
+}
+
+func NewErasureRepository(db *sql.DB) *ErasureRepository {
+	return &ErasureRepository{db: db}
+}
+
+// erasedOwner owns the tombstones an erasure leaves for other users.
+var erasedOwner = uuid.Nil.String()
+
+// erasureHash is the only form an identifier is kept in after erasure.
+func erasureHash(id string) string {
+	if u, err := uuid.Parse(id); err == nil {
+		id = u.String()
+	}
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// queryIDs returns the single text column of every row query yields.
+func queryIDs(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]string, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+/*
+Erase removes userID from every table in one transaction.
+
+The user's items are the ones they created plus every item of a
+workspace they own. Rows other users sync (items the user created in
+someone else's workspace, items they shared, their comments on other
+items and the shares they granted) become versioned tombstones owned by
+erasedOwner, so those users' devices learn of the delete. Everything
+else is deleted children first so foreign keys hold throughout; replies
+and links of other users that pointed at deleted rows are detached, and
+other creators of deleted items get their usage released.
+
+Any table with a user_id column is swept at the end, so tables added
+later are erased without touching this method; one that references
+another table needs its own step above the sweep.
+
+Erasure is refused while the user owns a team workspace with other
+members, since it would take their shared items with it.
+*/
+func (r *ErasureRepository) Erase(ctx context.Context, userID string) (*domain.Erasure, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	erasure := &domain.Erasure{SubjectHash: erasureHash(userID), DeletedRows: map[string]int64{}}
+
+	// 1️⃣ Owned workspaces must have no other members left
+	var shared string
+	err = tx.QueryRowContext(ctx, `
+		SELECT w.id
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id
+		WHERE w.owner_id = $1 AND m.user_id <> $1 AND m.removed = false
+		LIMIT 1
+	`, userID).Scan(&shared)
+	if err == nil {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "workspaces",
+			Message: "workspace " + shared + " still has other members; remove them first",
+		})
+	}
+	if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	// 2️⃣ Lock the items to erase; the ones in other workspaces or still
+	// shared become tombstones, the rest are deleted
+	type erasedItem struct {
+		id, workspaceID string
+		personal        bool
+	}
+	var (
+		itemIDs    = []string{}
+		tombstones []erasedItem
+	)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT
+			i.id::text,
+			i.workspace_id::text,
+			i.personal,
+			COALESCE(w.owner_id, i.workspace_id) <> $1
+			OR EXISTS (
+				SELECT 1 FROM item_shares s
+				WHERE s.item_id = i.id AND s.user_id <> $1 AND s.revoked = false
+			)
+		FROM items i
+		LEFT JOIN workspaces w ON w.id = i.workspace_id AND NOT i.personal
+		WHERE i.user_id = $1 OR COALESCE(w.owner_id, i.workspace_id) = $1
+		FOR UPDATE OF i
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var (
+			item      erasedItem
+			tombstone bool
+		)
+		if err := rows.Scan(&item.id, &item.workspaceID, &item.personal, &tombstone); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if tombstone {
+			tombstones = append(tombstones, item)
+		} else {
+			itemIDs = append(itemIDs, item.id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	// 3️⃣ Keep unused refresh tokens, hashed, so their devices are told
+	// the account is gone
+	tokenIDs, err := queryIDs(ctx, tx, `
+		SELECT t.id::text
+		FROM refresh_tokens t
+		JOIN devices d ON d.id = t.device_id
+		WHERE d.user_id = $1 AND t.used_at IS NULL
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	tokenHashes := make([]string, 0, len(tokenIDs))
+	for _, id := range tokenIDs {
+		tokenHashes = append(tokenHashes, erasureHash(id))
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO erased_refresh_tokens (id_hash, erased_at)
+		SELECT unnest($1::text[]), now()
+		ON CONFLICT DO NOTHING
+	`, tokenHashes); err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Detach other users' rows and release their usage
+	tombstoneIDs := make([]string, 0, len(tombstones))
+	for _, item := range tombstones {
+		tombstoneIDs = append(tombstoneIDs, item.id)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE comments SET parent_id = NULL
+		WHERE parent_id IN (SELECT id FROM comments WHERE item_id = ANY($1::uuid[]))
+		AND item_id <> ALL($1::uuid[])
+	`, itemIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE item_links SET target_id = NULL
+		WHERE (target_id = ANY($2::uuid[]) OR target_id = ANY($3::uuid[]))
+		AND user_id <> $1 AND source_id <> ALL($2::uuid[])
+	`, userID, itemIDs, tombstoneIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_usage u SET
+			item_count = u.item_count - d.item_count,
+			content_bytes = u.content_bytes - d.content_bytes,
+			updated_at = now()
+		FROM (
+			SELECT user_id, count(*) AS item_count, sum(octet_length(title) + octet_length(content)) AS content_bytes
+			FROM items
+			WHERE id = ANY($2::uuid[]) AND user_id <> $1 AND deleted = false
+			GROUP BY user_id
+		) d
+		WHERE u.user_id = d.user_id
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Tombstone what other users sync, one version per workspace
+	versions := map[string]int{}
+	workspaceVersion := func(workspaceID string, personal bool) (int, error) {
+		if v, ok := versions[workspaceID]; ok {
+			return v, nil
+		}
+		v, err := nextWorkspaceVersion(ctx, tx, workspaceID, personal)
+		versions[workspaceID] = v
+		return v, err
+	}
+	record := func(entityType, entityID string, version int) error {
+		return recordMutation(ctx, tx, mutationRecord{
+			MutationID:   uuid.NewString(),
+			UserID:       erasedOwner,
+			EntityType:   entityType,
+			EntityID:     entityID,
+			MutationType: "erase",
+			Version:      version,
+		})
+	}
+
+	for _, item := range tombstones {
+		version, err := workspaceVersion(item.workspaceID, item.personal)
+		if err != nil {
+			return nil, err
+		}
+		if err := purgeItemTx(ctx, tx, item.id, item.personal, version); err != nil {
+			return nil, err
+		}
+		if err := record("item", item.id, version); err != nil {
+			return nil, err
+		}
+	}
+
+	type erasedComment struct {
+		id, workspaceID string
+		personal        bool
+	}
+	var comments []erasedComment
+	rows, err = tx.QueryContext(ctx, `
+		SELECT c.id::text, i.workspace_id::text, i.personal
+		FROM comments c
+		JOIN items i ON i.id = c.item_id
+		WHERE c.user_id = $1 AND c.item_id <> ALL($2::uuid[])
+	`, userID, itemIDs)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c erasedComment
+		if err := rows.Scan(&c.id, &c.workspaceID, &c.personal); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+
+	for _, c := range comments {
+		version, err := workspaceVersion(c.workspaceID, c.personal)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE comments
+			SET deleted = true, body = '', user_id = $1, version = $2, updated_at = now()
+			WHERE id = $3
+		`, erasedOwner, version, c.id); err != nil {
+			return nil, err
+		}
+		if err := record("comment", c.id, version); err != nil {
+			return nil, err
+		}
+	}
+
+	// collaborators get access_revoked for the user's shared items
+	if len(tombstones) > 0 {
+		shareVersion, err := nextVersion(ctx, tx)
+		if err != nil {
+			return nil, err
+		}
+		sharedIDs, err := queryIDs(ctx, tx, `
+			UPDATE item_shares
+			SET revoked = true, owner_id = $1, version = $2, updated_at = now()
+			WHERE item_id = ANY($3::uuid[]) AND owner_id = $4 AND user_id <> $4
+			RETURNING item_id::text
+		`, erasedOwner, shareVersion, tombstoneIDs, userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range sharedIDs {
+			if err := record("item_share", id, shareVersion); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// personal workspaces are keyed by user ID, so those move too
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE items
+		SET
+			user_id = $1,
+			workspace_id = CASE WHEN personal THEN $1::uuid ELSE workspace_id END
+		WHERE id = ANY($2::uuid[])
+	`, erasedOwner, tombstoneIDs); err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Delete, children first. erased collects entity IDs for the
+	// mutation and audit logs.
+	erased := append([]string{}, itemIDs...)
+	del := func(table, query string, args ...any) ([]string, error) {
+		ids, err := queryIDs(ctx, tx, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		erasure.DeletedRows[table] += int64(len(ids))
+		erased = append(erased, ids...)
+		return ids, nil
+	}
+	exec := func(table, query string, args ...any) error {
+		res, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		n, _ := res.RowsAffected()
+		erasure.DeletedRows[table] += n
+		return nil
+	}
+
+	if _, err := del("comments", `
+		DELETE FROM comments WHERE user_id = $1 OR item_id = ANY($2::uuid[]) RETURNING id::text
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err := exec("item_links", `
+		DELETE FROM item_links WHERE user_id = $1 OR source_id = ANY($2::uuid[])
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err := exec("item_tags", `
+		DELETE FROM item_tags
+		WHERE user_id = $1 OR item_id = ANY($2::uuid[])
+		OR tag_id IN (SELECT id FROM tags WHERE user_id = $1)
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if _, err := del("attachments", `
+		DELETE FROM attachments WHERE user_id = $1 OR item_id = ANY($2::uuid[]) RETURNING id::text
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err := exec("item_shares", `
+		DELETE FROM item_shares WHERE user_id = $1 OR owner_id = $1 OR item_id = ANY($2::uuid[])
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err := exec("items", `
+		DELETE FROM items WHERE id = ANY($1::uuid[])
+	`, itemIDs); err != nil {
+		return nil, err
+	}
+	if _, err := del("collections", `
+		DELETE FROM collections WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if _, err := del("tags", `
+		DELETE FROM tags WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if err := exec("workspace_members", `
+		DELETE FROM workspace_members
+		WHERE user_id = $1 OR workspace_id IN (SELECT id FROM workspaces WHERE owner_id = $1)
+	`, userID); err != nil {
+		return nil, err
+	}
+	if _, err := del("workspaces", `
+		DELETE FROM workspaces WHERE owner_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		DELETE FROM blobs WHERE user_id = $1
+		RETURNING user_id, sha256, size, content_type, created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		b := &domain.Blob{}
+		if err := rows.Scan(&b.UserID, &b.SHA256, &b.Size, &b.ContentType, &b.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		erasure.Blobs = append(erasure.Blobs, b)
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	rows.Close()
+	erasure.DeletedRows["blobs"] += int64(len(erasure.Blobs))
+
+	if erasure.UploadIDs, err = del("uploads", `
+		DELETE FROM uploads WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if err := exec("refresh_tokens", `
+		DELETE FROM refresh_tokens WHERE device_id IN (SELECT id FROM devices WHERE user_id = $1)
+	`, userID); err != nil {
+		return nil, err
+	}
+	if _, err := del("devices", `
+		DELETE FROM devices WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if _, err := del("api_keys", `
+		DELETE FROM api_keys WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if erasure.ExportIDs, err = del("export_jobs", `
+		DELETE FROM export_jobs WHERE user_id = $1 RETURNING id::text
+	`, userID); err != nil {
+		return nil, err
+	}
+	if err := exec("changes", `
+		DELETE FROM changes WHERE user_id = $1 OR item_id = ANY($2::uuid[])
+	`, userID, itemIDs); err != nil {
+		return nil, err
+	}
+	if err := exec("mutation_log", `
+		DELETE FROM mutation_log WHERE user_id = $1 OR entity_id = ANY($2::uuid[])
+	`, userID, erased); err != nil {
+		return nil, err
+	}
+
+	// the append-only trigger lets this transaction delete
+	if _, err := tx.ExecContext(ctx, `SET LOCAL app.erasure = 'on'`); err != nil {
+		return nil, err
+	}
+	if err := exec("audit_log", `
+		DELETE FROM audit_log WHERE user_id = $1 OR entity_id = ANY($2::text[])
+	`, userID, erased); err != nil {
+		return nil, err
+	}
+
+	// 7️⃣ Sweep every table with a user_id column, including the ones
+	// above (they are empty for the user by now)
+	tables, err := queryIDs(ctx, tx, `
+		SELECT c.table_name::text
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema()
+		AND c.column_name = 'user_id'
+		AND t.table_type = 'BASE TABLE'
+		ORDER BY c.table_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		query := `DELETE FROM ` + pgx.Identifier{table}.Sanitize() + ` WHERE user_id = $1`
+		if err := exec(table, query, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	// 8️⃣ Proof of erasure; erasing again (rows that raced the first
+	// erasure) refreshes it
+	deletedRows, err := json.Marshal(erasure.DeletedRows)
+	if err != nil {
+		return nil, err
+	}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO account_erasures (subject_hash, erased_at, deleted_rows)
+		VALUES ($1, clock_timestamp(), $2::jsonb)
+		ON CONFLICT (subject_hash) DO UPDATE SET
+			erased_at = EXCLUDED.erased_at,
+			deleted_rows = EXCLUDED.deleted_rows
+		RETURNING erased_at
+	`, erasure.SubjectHash, string(deletedRows)).Scan(&erasure.ErasedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 9️⃣ Audit, without the user
+	if err := appendAuditTx(ctx, tx, &domain.AuditEvent{
+		Category: domain.AuditAccount,
+		Action:   "erase",
+		Outcome:  domain.AuditSuccess,
+		Detail:   map[string]any{"subject_hash": erasure.SubjectHash},
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	middleware.LogWithContext(ctx, "account erased", "subject_hash", erasure.SubjectHash)
+	return erasure, nil
+}
+
+func (r *ErasureRepository) AccountErased(ctx context.Context, userID string) (bool, error) {
+	return accountErased(ctx, r.db, userID)
+}
+
+// accountErased reports whether userID was erased (hashed in account_erasures).
+func accountErased(ctx context.Context, q queryer, userID string) (bool, error) {
+	var erased bool
+	err := q.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM account_erasures WHERE subject_hash = $1)
+	`, erasureHash(userID)).Scan(&erased)
+	return erased, err
+}
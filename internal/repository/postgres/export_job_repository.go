@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ExportJobRepository struct {
+	db *sql.DB
+}
+
+func NewExportJobRepository(db *sql.DB) *ExportJobRepository {
+	return &ExportJobRepository{db: db}
+}
+
+const exportJobColumns = `id, user_id, status, COALESCE(error, ''), COALESCE(size, 0), created_at, completed_at, expires_at`
+
+func scanExportJob(row rowScanner) (*domain.ExportJob, error) {
+	j := &domain.ExportJob{}
+	var completedAt, expiresAt sql.NullTime
+	err := row.Scan(&j.ID, &j.UserID, &j.Status, &j.Error, &j.Size, &j.CreatedAt, &completedAt, &expiresAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		j.CompletedAt = &completedAt.Time
+	}
+	if expiresAt.Valid {
+		j.ExpiresAt = &expiresAt.Time
+	}
+	return j, nil
+}
+
+func (r *ExportJobRepository) Create(ctx context.Context, job *domain.ExportJob) (*domain.ExportJob, error) {
+	// the partial unique index allows one pending / running job per user
+	created, err := scanExportJob(r.db.QueryRowContext(ctx, `
+		INSERT INTO export_jobs (id, user_id, status, created_at)
+		VALUES ($1, $2, 'pending', now())
+		ON CONFLICT DO NOTHING
+		RETURNING `+exportJobColumns,
+		job.ID, job.UserID,
+	))
+	if err != domain.ErrNotFound {
+		return created, err
+	}
+
+	return scanExportJob(r.db.QueryRowContext(ctx, `
+		SELECT `+exportJobColumns+`
+		FROM export_jobs
+		WHERE user_id = $1 AND status IN ('pending', 'running')
+	`, job.UserID))
+}
+
+func (r *ExportJobRepository) Get(ctx context.Context, userID string, id string) (*domain.ExportJob, error) {
+	return scanExportJob(r.db.QueryRowContext(ctx, `
+		SELECT `+exportJobColumns+`
+		FROM export_jobs
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+}
+
+func (r *ExportJobRepository) Claim(ctx context.Context, staleAfter time.Duration) (*domain.ExportJob, error) {
+	return scanExportJob(r.db.QueryRowContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'running', started_at = now()
+		WHERE id = (
+			SELECT id FROM export_jobs
+			WHERE status = 'pending'
+			OR (status = 'running' AND started_at < now() - $1 * interval '1 second')
+			ORDER BY created_at ASC
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+exportJobColumns,
+		staleAfter.Seconds(),
+	))
+}
+
+func (r *ExportJobRepository) Complete(ctx context.Context, id string, size int64, expiresAt time.Time) error {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'complete', size = $1, completed_at = now(), expires_at = $2
+		WHERE id = $3
+	`, size, expiresAt.UTC(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func (r *ExportJobRepository) Fail(ctx context.Context, id string, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE export_jobs
+		SET status = 'failed', error = $1, completed_at = now()
+		WHERE id = $2
+	`, reason, id)
+	return err
+}
+
+func (r *ExportJobRepository) DeleteExpired(ctx context.Context, now time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		DELETE FROM export_jobs
+		WHERE expires_at < $1
+		RETURNING id
+	`, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -3,28 +3,208 @@ package postgres
 import (
 	domain "Offline-First/internal/domain/model"
 	"Offline-First/internal/http/middleware"
+	"Offline-First/internal/ordering"
+	"Offline-First/internal/repository"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
 )
 
+const itemColumns = `id, user_id, workspace_id, type, title, content, metadata, collection_id, version, deleted, created_at, updated_at,
+	encryption_key_id, encryption_algorithm, encryption_title_nonce, encryption_content_nonce, position, purged, personal`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanItem(row rowScanner) (*domain.Item, error) {
+	item := &domain.Item{}
+	var metadata []byte
+	var collectionID sql.NullString
+	var keyID, algorithm, titleNonce, contentNonce sql.NullString
+
+	if err := row.Scan(
+		&item.ID,
+		&item.UserID,
+		&item.WorkspaceID,
+		&item.Type,
+		&item.Title,
+		&item.Content,
+		&metadata,
+		&collectionID,
+		&item.Version,
+		&item.Deleted,
+		&item.CreatedAt,
+		&item.UpdatedAt,
+		&keyID,
+		&algorithm,
+		&titleNonce,
+		&contentNonce,
+		&item.Position,
+		&item.Purged,
+		&item.Personal,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(metadata, &item.Metadata); err != nil {
+		return nil, err
+	}
+	if collectionID.Valid {
+		item.CollectionID = &collectionID.String
+	}
+	if keyID.Valid {
+		item.Encryption = &domain.Encryption{
+			KeyID:        keyID.String,
+			Algorithm:    algorithm.String,
+			TitleNonce:   titleNonce.String,
+			ContentNonce: contentNonce.String,
+		}
+	}
+	return item, nil
+}
+
+// encryptionArgs returns the four envelope columns, all NULL for plaintext items.
+func encryptionArgs(e *domain.Encryption) []any {
+	if e == nil {
+		return []any{nil, nil, nil, nil}
+	}
+	return []any{e.KeyID, e.Algorithm, e.TitleNonce, e.ContentNonce}
+}
+
+// visibleTo matches items in the personal workspace of the user in $1,
+// in a workspace they are a member of, or actively shared with them.
+const visibleTo = `((personal AND workspace_id = $1) OR (NOT personal AND EXISTS (
+	SELECT 1 FROM workspace_members m
+	WHERE m.workspace_id = items.workspace_id AND m.user_id = $1 AND m.removed = false
+)) OR EXISTS (
+	SELECT 1 FROM item_shares s
+	WHERE s.item_id = items.id AND s.user_id = $1 AND s.revoked = false
+))`
+
+// withRole appends the caller's role column to the standard item scan.
+type withRole struct {
+	row  rowScanner
+	role *sql.NullString
+}
+
+func (w withRole) Scan(dest ...any) error {
+	return w.row.Scan(append(dest, w.role)...)
+}
+
+/*
+accessibleItemTx loads an item the caller can see, together with the
+caller's role: owner in their personal workspace, otherwise the
+workspace membership role or, failing that, the share role.
+Anything else is not found.
+*/
+func accessibleItemTx(ctx context.Context, q queryer, callerID string, id string) (*domain.Item, string, error) {
+	var role sql.NullString
+	item, err := scanItem(withRole{row: q.QueryRowContext(ctx, `
+		SELECT `+itemColumns+`,
+			CASE WHEN personal AND workspace_id = $2 THEN 'owner' ELSE COALESCE(
+				(SELECT m.role FROM workspace_members m
+				WHERE NOT items.personal
+				AND m.workspace_id = items.workspace_id AND m.user_id = $2 AND m.removed = false),
+				(SELECT s.role FROM item_shares s
+				WHERE s.item_id = items.id AND s.user_id = $2 AND s.revoked = false)
+			) END
+		FROM items
+		WHERE id = $1
+	`, id, callerID), role: &role})
+	if err == sql.ErrNoRows || (err == nil && !role.Valid) {
+		return nil, "", domain.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return item, role.String, nil
+}
+
+/*
+workspaceRoleTx returns the user's role in a workspace. The personal
+workspace always has role owner; unknown workspaces and former
+members are not found.
+*/
+func workspaceRoleTx(ctx context.Context, q queryer, workspaceID string, userID string) (string, error) {
+	if workspaceID == userID {
+		return domain.RoleOwner, nil
+	}
+
+	var role string
+	err := q.QueryRowContext(ctx, `
+		SELECT role FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2 AND removed = false
+	`, workspaceID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", domain.ErrNotFound
+	}
+	return role, err
+}
+
+// queryer is satisfied by *sql.DB and *sql.Tx.
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// nullableID maps nil / "" to SQL NULL.
+func nullableID(id *string) any {
+	if id == nil || *id == "" {
+		return nil
+	}
+	return *id
+}
+
+// requireLiveCollectionTx rejects items pointing at a missing or deleted collection.
+func requireLiveCollectionTx(ctx context.Context, tx *sql.Tx, userID string, collectionID *string) error {
+	if collectionID == nil || *collectionID == "" {
+		return nil
+	}
+
+	// block concurrent deletes of the target collection
+	if err := lockTreeTx(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	var deleted bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT deleted FROM collections WHERE id = $1 AND user_id = $2
+	`, *collectionID, userID).Scan(&deleted)
+	if err == sql.ErrNoRows || (err == nil && deleted) {
+		return domain.NewValidationError(domain.FieldError{
+			Field:   "collection_id",
+			Message: "collection not found",
+		})
+	}
+	return err
+}
+
+func encodeMetadata(metadata map[string]any) (string, error) {
+	if metadata == nil {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(metadata)
+	return string(raw), err
+}
+
 type ItemRepository struct {
-	db *sql.DB
+	db    *sql.DB
+	quota domain.Quota
 }
 
-func NewItemRepository(db *sql.DB) *ItemRepository {
-	return &ItemRepository{db: db}
+// NewItemRepository charges item writes to their owner's usage, capped by quota.
+func NewItemRepository(db *sql.DB, quota domain.Quota) *ItemRepository {
+	return &ItemRepository{db: db, quota: quota}
 }
 
 func (r *ItemRepository) NextVersion(ctx context.Context, tx *sql.Tx) (int, error) {
-	var v int
-	err := tx.QueryRowContext(ctx, `
-		UPDATE sync_state
-		SET latest_version = latest_version + 1
-		WHERE id = 1
-		RETURNING latest_version
-	`).Scan(&v)
+	return nextVersion(ctx, tx)
+}
 
-	return v, err
+func (r *ItemRepository) LatestVersion(ctx context.Context, workspaceID string) (int, error) {
+	return committedVersion(ctx, r.db, workspaceID)
 }
 
 func (r *ItemRepository) Create(ctx context.Context, item *domain.Item, mutationID string) (*domain.Item, error) {
@@ -35,7 +215,7 @@ func (r *ItemRepository) Create(ctx context.Context, item *domain.Item, mutation
 	defer tx.Rollback()
 
 	// 1️⃣ Idempotency check
-	if appliedVersion, ok, err := r.getAppliedVersion(ctx, tx, mutationID); err != nil {
+	if appliedVersion, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
 		return nil, err
 	} else if ok {
 		middleware.LogWithContext(
@@ -63,55 +243,101 @@ func (r *ItemRepository) Create(ctx context.Context, item *domain.Item, mutation
 		return nil, err
 	}
 
-	// 3️⃣ Allocate global version
-	version, err := r.NextVersion(ctx, tx)
+	// 3️⃣ Creating in a team workspace needs an editing role there
+	if item.WorkspaceID == "" {
+		item.WorkspaceID = item.UserID
+	}
+	item.Personal = item.WorkspaceID == item.UserID
+	role, err := workspaceRoleTx(ctx, tx, item.WorkspaceID, item.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if role == domain.RoleViewer {
+		return nil, domain.ErrForbidden
+	}
+
+	// 4️⃣ Allocate the workspace version (global for personal workspaces)
+	version, err := nextWorkspaceVersion(ctx, tx, item.WorkspaceID, item.Personal)
 	if err != nil {
 		return nil, err
 	}
 
 	middleware.LogWithContext(
 		ctx,
-		"global version allocated (create)",
+		"version allocated (create)",
 		"item_id", item.ID,
+		"workspace_id", item.WorkspaceID,
 		"new_version", version,
 	)
 
+	// collections are personal
+	if !item.InPersonalWorkspace() && nullableID(item.CollectionID) != nil {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "collection_id",
+			Message: "items in a team workspace cannot be in a collection",
+		})
+	}
+	if err := requireLiveCollectionTx(ctx, tx, item.UserID, item.CollectionID); err != nil {
+		return nil, err
+	}
+
+	metadata, err := encodeMetadata(domain.MergeMetadata(nil, item.Metadata))
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
 		INSERT INTO items (
-			id, user_id, type, title, content, version, deleted, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, false, now(), now())
+			id, user_id, type, title, content, metadata, collection_id, version, deleted, created_at, updated_at,
+			encryption_key_id, encryption_algorithm, encryption_title_nonce, encryption_content_nonce, workspace_id, position, personal
+		) VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8, false, now(), now(), $9, $10, $11, $12, $13, $14, $15)
 	`
-	_, err = tx.ExecContext(
-		ctx,
-		query,
+	args := append([]any{
 		item.ID,
 		item.UserID,
 		item.Type,
 		item.Title,
 		item.Content,
+		metadata,
+		nullableID(item.CollectionID),
 		version,
-	)
+	}, encryptionArgs(item.Encryption)...)
+	args = append(args, item.WorkspaceID, item.Position, item.Personal)
+	_, err = tx.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// 5️⃣ Record mutation
-	_, err = tx.ExecContext(
-		ctx,
-		`
-		INSERT INTO mutation_log (
-			mutation_id,
-			item_id,
-			mutation_type,
-			applied_version
-		)
-		VALUES ($1, $2, 'create', $3)
-		`,
-		mutationID,
-		item.ID,
-		version,
-	)
+	if len(item.Position) > ordering.RebalanceLength {
+		if err := rebalanceTx(ctx, tx, item.WorkspaceID, item.CollectionID, version); err != nil {
+			return nil, err
+		}
+	}
+
+	// 5️⃣ Charge the creator's usage (fails past the quota)
+	err = chargeUsageTx(ctx, tx, item.UserID, r.quota, domain.Usage{
+		ItemCount:    1,
+		ContentBytes: contentBytes(item.Title, item.Content),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Rebuild [[link]] index in the same transaction
+	if err := replaceLinksTx(ctx, tx, item); err != nil {
+		return nil, err
+	}
+
+	// 7️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       item.UserID,
+		EntityType:   "item",
+		EntityID:     item.ID,
+		MutationType: "create",
+		Version:      version,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -124,17 +350,90 @@ func (r *ItemRepository) Create(ctx context.Context, item *domain.Item, mutation
 	return created, tx.Commit()
 }
 
-func (r *ItemRepository) ListByUser(ctx context.Context, userId string) ([]*domain.Item, error) {
+/*
+ListByUser pages through a user's items with keyset pagination.
+
+The sort column is always paired with id as a tie-breaker, so pages
+never skip or repeat items even when many share the same timestamp.
+*/
+func (r *ItemRepository) ListByUser(ctx context.Context, userId string, opts repository.ItemListOptions) ([]*domain.Item, string, error) {
+	where := []string{visibleTo}
+	args := []any{userId}
+
+	if !opts.IncludeDeleted {
+		where = append(where, "deleted = false")
+	}
+
+	if opts.WorkspaceID != "" {
+		args = append(args, opts.WorkspaceID)
+		where = append(where, fmt.Sprintf("workspace_id = $%d", len(args)))
+	}
+
+	switch opts.CollectionID {
+	case "":
+	case repository.CollectionRoot:
+		where = append(where, "collection_id IS NULL")
+	default:
+		args = append(args, opts.CollectionID)
+		where = append(where, fmt.Sprintf("collection_id = $%d", len(args)))
+	}
+
+	if opts.Type != "" {
+		args = append(args, opts.Type)
+		where = append(where, fmt.Sprintf("type = $%d", len(args)))
+	}
+
+	if opts.UpdatedSince != nil {
+		args = append(args, *opts.UpdatedSince)
+		where = append(where, fmt.Sprintf("updated_at > $%d", len(args)))
+	}
+
+	if opts.MetadataHas != "" {
+		args = append(args, opts.MetadataHas)
+		where = append(where, fmt.Sprintf("metadata ? $%d", len(args)))
+	}
+
+	for key, value := range opts.MetadataEquals {
+		args = append(args, key, value)
+		where = append(where, fmt.Sprintf("metadata ->> $%d = $%d", len(args)-1, len(args)))
+	}
+
+	field := opts.SortField
+	if field == "" {
+		field = repository.SortUpdatedAt
+	}
+	cast, ok := sortColumns[field]
+	if !ok {
+		return nil, "", domain.NewValidationError(domain.FieldError{Field: "sort", Message: "unsupported sort field"})
+	}
+
+	direction, cmp := "ASC", ">"
+	if opts.SortDesc {
+		direction, cmp = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		cursor, err := decodeCursor(opts.Cursor, field, opts.SortDesc)
+		if err != nil {
+			return nil, "", err
+		}
+		args = append(args, cursor.Value, cursor.ID)
+		where = append(where, fmt.Sprintf("(%s, id) %s ($%d::%s, $%d::uuid)", field, cmp, len(args)-1, cast, len(args)))
+	}
+
+	// fetch one extra row to know whether another page exists
+	args = append(args, opts.Limit+1)
+
 	query := `
-		SELECT id, user_id, type, title, content, version, deleted, created_at, updated_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE user_id = $1 AND deleted = false
-		ORDER BY updated_at DESC 
-	`
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY ` + field + ` ` + direction + `, id ` + direction + `
+		LIMIT $` + fmt.Sprint(len(args))
 
-	rows, err := r.db.QueryContext(ctx, query, userId)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	defer rows.Close()
@@ -142,55 +441,50 @@ func (r *ItemRepository) ListByUser(ctx context.Context, userId string) ([]*doma
 	items := []*domain.Item{}
 
 	for rows.Next() {
-		item := &domain.Item{}
-
-		err := rows.Scan(
-			&item.ID,
-			&item.UserID,
-			&item.Type,
-			&item.Title,
-			&item.Content,
-			&item.Version,
-			&item.Deleted,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		)
-
+		item, err := scanItem(rows)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		items = append(items, item)
 	}
-	return items, nil
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	nextCursor := ""
+	if len(items) > opts.Limit {
+		items = items[:opts.Limit]
+		nextCursor = encodeCursor(field, opts.SortDesc, items[len(items)-1])
+	}
+	return items, nextCursor, nil
+}
+
+// GetByID returns an item the user owns or collaborates on.
+func (r *ItemRepository) GetByID(ctx context.Context, userID string, id string) (*domain.Item, error) {
+	query := `
+		SELECT ` + itemColumns + `
+		FROM items
+		WHERE id = $2 AND ` + visibleTo
+	item, err := scanItem(r.db.QueryRowContext(ctx, query, userID, id))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return item, err
 }
 
 func (r *ItemRepository) GetByIdTx(ctx context.Context, tx *sql.Tx,
 	userID string, id string) (*domain.Item, error) {
 	query := `
-		SELECT id, user_id, type, title, content, version, deleted, created_at, updated_at
+		SELECT ` + itemColumns + `
 		FROM items
 		WHERE id = $1 AND user_id=$2
 	`
-	row := tx.QueryRowContext(ctx, query, id, userID)
-
-	var item domain.Item
-	err := row.Scan(
-		&item.ID,
-		&item.UserID,
-		&item.Type,
-		&item.Title,
-		&item.Content,
-		&item.Version,
-		&item.Deleted,
-		&item.CreatedAt,
-		&item.UpdatedAt,
-	)
-
+	item, err := scanItem(tx.QueryRowContext(ctx, query, id, userID))
 	if err == sql.ErrNoRows {
 		return nil, domain.ErrNotFound
 	}
-	return &item, err
+	return item, err
 }
 
 func (r *ItemRepository) Update(ctx context.Context, item *domain.Item, mutationID string) (*domain.Item, error) {
@@ -201,7 +495,7 @@ func (r *ItemRepository) Update(ctx context.Context, item *domain.Item, mutation
 	defer tx.Rollback()
 
 	// 1️⃣ Idempotency check
-	if appliedVersion, ok, err := r.getAppliedVersion(ctx, tx, mutationID); err != nil {
+	if appliedVersion, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
 		return nil, err
 	} else if ok {
 		// Already applied → return current item state
@@ -211,7 +505,7 @@ func (r *ItemRepository) Update(ctx context.Context, item *domain.Item, mutation
 			"applied_version", appliedVersion,
 		)
 
-		current, err := r.GetByIdTx(ctx, tx, item.UserID, item.ID)
+		current, _, err := accessibleItemTx(ctx, tx, item.UserID, item.ID)
 		if err != nil {
 			return nil, err
 		}
@@ -219,11 +513,14 @@ func (r *ItemRepository) Update(ctx context.Context, item *domain.Item, mutation
 		return current, nil
 	}
 
-	// 2️⃣ Load current state
-	current, err := r.GetByIdTx(ctx, tx, item.UserID, item.ID)
+	// 2️⃣ Load current state; owners and editors may update
+	current, role, err := accessibleItemTx(ctx, tx, item.UserID, item.ID)
 	if err != nil {
 		return nil, err
 	}
+	if role == domain.RoleViewer {
+		return nil, domain.ErrForbidden
+	}
 
 	if current.Version != item.Version {
 		middleware.LogWithContext(
@@ -237,60 +534,141 @@ func (r *ItemRepository) Update(ctx context.Context, item *domain.Item, mutation
 		return nil, domain.NewConflictError(current)
 	}
 
-	// 3️⃣ Allocate global version
-	newVersion, err := r.NextVersion(ctx, tx)
+	if current.Purged {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "id",
+			Message: "item was purged and cannot be restored",
+		})
+	}
+
+	// a client that cannot decrypt must not overwrite ciphertext with plaintext
+	if current.Encrypted() && !item.Encrypted() {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "encryption",
+			Message: "item is encrypted; updates must include the encryption envelope",
+		})
+	}
+
+	if item.WorkspaceID != "" && item.WorkspaceID != current.WorkspaceID {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "workspace_id",
+			Message: "items cannot move between workspaces",
+		})
+	}
+
+	// collections are personal, so only the owner of a personal item can move it
+	if item.CollectionID != nil && nullableID(item.CollectionID) != nullableID(current.CollectionID) {
+		if !current.InPersonalWorkspace() {
+			return nil, domain.NewValidationError(domain.FieldError{
+				Field:   "collection_id",
+				Message: "items in a team workspace cannot be in a collection",
+			})
+		}
+		if role != domain.RoleOwner {
+			return nil, domain.ErrForbidden
+		}
+	}
+
+	// the row keeps its creator and workspace; the mutation is recorded for the caller
+	callerID := item.UserID
+	item.UserID = current.UserID
+	item.WorkspaceID = current.WorkspaceID
+	item.Personal = current.Personal
+
+	// 3️⃣ Allocate the workspace version (global for personal workspaces)
+	newVersion, err := nextWorkspaceVersion(ctx, tx, current.WorkspaceID, current.Personal)
 	if err != nil {
 		return nil, err
 	}
 
 	middleware.LogWithContext(
 		ctx,
-		"global version allocated (update)",
+		"version allocated (update)",
 		"item_id", item.ID,
+		"workspace_id", current.WorkspaceID,
 		"new_version", newVersion,
 	)
 
+	// 4️⃣ Merge metadata key by key (null removes a key); encrypted
+	// items replace it, the client resolves merges after decrypting
+	base := current.Metadata
+	if item.Encrypted() {
+		base = nil
+	}
+	metadata, err := encodeMetadata(domain.MergeMetadata(base, item.Metadata))
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Move between collections (nil keeps the current one)
+	collectionID := current.CollectionID
+	if item.CollectionID != nil {
+		if err := requireLiveCollectionTx(ctx, tx, item.UserID, item.CollectionID); err != nil {
+			return nil, err
+		}
+		collectionID = item.CollectionID
+	}
+
 	query := `
 		UPDATE items
 		SET 
 			title = $1,
 			content = $2,
 			type = $3,
-			version = $4,
+			metadata = $4::jsonb,
+			collection_id = $5,
+			version = $6,
 			deleted = false,
-			updated_at = now()
-		WHERE id = $5 
-		AND user_id = $6
+			updated_at = now(),
+			encryption_key_id = $9,
+			encryption_algorithm = $10,
+			encryption_title_nonce = $11,
+			encryption_content_nonce = $12
+		WHERE id = $7 
+		AND user_id = $8
 	`
-	_, err = r.db.ExecContext(ctx, query,
+	args := append([]any{
 		item.Title,
 		item.Content,
 		item.Type,
+		metadata,
+		nullableID(collectionID),
 		newVersion,
 		item.ID,
 		item.UserID,
-	)
+	}, encryptionArgs(item.Encryption)...)
+	_, err = tx.ExecContext(ctx, query, args...)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// 5️⃣ Record mutation
-	_, err = tx.ExecContext(
-		ctx,
-		`
-		INSERT INTO mutation_log (
-			mutation_id,
-			item_id,
-			mutation_type,
-			applied_version
-		)
-		VALUES ($1, $2, 'update', $3)
-		`,
-		mutationID,
-		item.ID,
-		newVersion,
-	)
+	// 6️⃣ Charge the owner for the size change; updating a deleted
+	// item resurrects it, so it counts again
+	delta := domain.Usage{ContentBytes: contentBytes(item.Title, item.Content)}
+	if current.Deleted {
+		delta.ItemCount = 1
+	} else {
+		delta.ContentBytes -= contentBytes(current.Title, current.Content)
+	}
+	if err := chargeUsageTx(ctx, tx, item.UserID, r.quota, delta); err != nil {
+		return nil, err
+	}
+
+	// Rebuild [[link]] index in the same transaction
+	if err := replaceLinksTx(ctx, tx, item); err != nil {
+		return nil, err
+	}
+
+	// 7️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "item",
+		EntityID:     item.ID,
+		MutationType: "update",
+		Version:      newVersion,
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -315,7 +693,7 @@ func (r *ItemRepository) SoftDelete(ctx context.Context, id string, userID strin
 	defer tx.Rollback()
 
 	// 1️⃣ Idempotency check
-	if appliedVersion, ok, err := r.getAppliedVersion(ctx, tx, mutationID); err != nil {
+	if appliedVersion, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
 		return nil, err
 	} else if ok {
 		middleware.LogWithContext(
@@ -325,7 +703,7 @@ func (r *ItemRepository) SoftDelete(ctx context.Context, id string, userID strin
 			"applied_version", appliedVersion,
 		)
 
-		current, err := r.GetByIdTx(ctx, tx, userID, id)
+		current, _, err := accessibleItemTx(ctx, tx, userID, id)
 		if err != nil {
 			return nil, err
 		}
@@ -333,11 +711,15 @@ func (r *ItemRepository) SoftDelete(ctx context.Context, id string, userID strin
 		return current, nil
 	}
 
-	// 2️⃣ Load current state
-	current, err := r.GetByIdTx(ctx, tx, userID, id)
+	// 2️⃣ Load current state; the owner may delete, and so may editors
+	// of a team workspace (editors of a shared item may not)
+	current, role, err := accessibleItemTx(ctx, tx, userID, id)
 	if err != nil {
 		return nil, err
 	}
+	if role != domain.RoleOwner && (role != domain.RoleEditor || current.InPersonalWorkspace()) {
+		return nil, domain.ErrForbidden
+	}
 
 	if current.Version != version {
 		middleware.LogWithContext(
@@ -351,16 +733,17 @@ func (r *ItemRepository) SoftDelete(ctx context.Context, id string, userID strin
 		return nil, domain.NewConflictError(current)
 	}
 
-	// 3️⃣ Allocate global version
-	newVersion, err := r.NextVersion(ctx, tx)
+	// 3️⃣ Allocate the workspace version (global for personal workspaces)
+	newVersion, err := nextWorkspaceVersion(ctx, tx, current.WorkspaceID, current.Personal)
 	if err != nil {
 		return nil, err
 	}
 
 	middleware.LogWithContext(
 		ctx,
-		"global version allocated (delete)",
+		"version allocated (delete)",
 		"item_id", id,
+		"workspace_id", current.WorkspaceID,
 		"new_version", newVersion,
 	)
 
@@ -371,48 +754,446 @@ func (r *ItemRepository) SoftDelete(ctx context.Context, id string, userID strin
 			deleted = true,
 			version = $1,
 			updated_at = now()
-		WHERE id = $2 AND user_id = $3
+		WHERE id = $2
 	`
 
-	_, err = tx.ExecContext(ctx, query, newVersion, id, userID)
+	_, err = tx.ExecContext(ctx, query, newVersion, id)
 	if err != nil {
 		return nil, err
 	}
 
-	// 5️⃣ Record mutation
-	_, err = tx.ExecContext(
+	// 5️⃣ Release the owner's usage
+	if !current.Deleted {
+		err = chargeUsageTx(ctx, tx, current.UserID, r.quota, domain.Usage{
+			ItemCount:    -1,
+			ContentBytes: -contentBytes(current.Title, current.Content),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "item",
+		EntityID:     id,
+		MutationType: "delete",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deletedItem, err := r.GetByIdTx(ctx, tx, current.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return deletedItem, tx.Commit()
+}
+
+/*
+Purge removes the content of a deleted item for good ("delete forever").
+
+The row stays as a tombstone (id, owner, workspace, version, deleted,
+purged) under a new version, so devices that have not synced the
+delete yet still learn about it from /changes. Comments, attachments
+and tag assignments of the item become tombstones in the same
+transaction and outgoing links are dropped; the blobs left
+unreferenced are released by the garbage collector.
+Usage was already released when the item was deleted.
+*/
+func (r *ItemRepository) Purge(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Item, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if appliedVersion, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(
+			ctx,
+			"mutation replayed (purge)",
+			"item_id", id,
+			"applied_version", appliedVersion,
+		)
+
+		current, _, err := accessibleItemTx(ctx, tx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = appliedVersion
+		return current, nil
+	}
+
+	// 2️⃣ Load current state; whoever may delete the item may purge it
+	current, role, err := accessibleItemTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if role != domain.RoleOwner && (role != domain.RoleEditor || current.InPersonalWorkspace()) {
+		return nil, domain.ErrForbidden
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(
+			ctx,
+			"version conflict (purge)",
+			"item_id", id,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+
+		return nil, domain.NewConflictError(current)
+	}
+	if !current.Deleted {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "id",
+			Message: "only deleted items can be purged",
+		})
+	}
+	if current.Purged {
+		return current, nil
+	}
+
+	// 3️⃣ Allocate the workspace version (global for personal workspaces)
+	newVersion, err := nextWorkspaceVersion(ctx, tx, current.WorkspaceID, current.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	middleware.LogWithContext(
 		ctx,
-		`
-		INSERT INTO mutation_log (
-			mutation_id,
-			item_id,
-			mutation_type,
-			applied_version
+		"version allocated (purge)",
+		"item_id", id,
+		"workspace_id", current.WorkspaceID,
+		"new_version", newVersion,
+	)
+
+	// 4️⃣ Strip the row and tombstone what hangs off it
+	if err := purgeItemTx(ctx, tx, id, current.InPersonalWorkspace(), newVersion); err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "item",
+		EntityID:     id,
+		MutationType: "purge",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	purged, err := r.GetByIdTx(ctx, tx, current.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return purged, tx.Commit()
+}
+
+/*
+purgeItemTx strips item id down to a deleted, purged tombstone at
+version (from the item's workspace counter) and tombstones its
+comments, attachments and tag assignments. Comments share the item's
+counter; tags and attachments are on the global one, which is the same
+counter only for personal workspaces. Outgoing links are a server-side
+index rebuilt on every write, not synced, so they are dropped.
+*/
+func purgeItemTx(ctx context.Context, tx *sql.Tx, id string, personal bool, version int) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE items
+		SET
+			type = '',
+			title = '',
+			content = '',
+			metadata = '{}'::jsonb,
+			collection_id = NULL,
+			position = '',
+			encryption_key_id = NULL,
+			encryption_algorithm = NULL,
+			encryption_title_nonce = NULL,
+			encryption_content_nonce = NULL,
+			deleted = true,
+			purged = true,
+			version = $1,
+			updated_at = now()
+		WHERE id = $2
+	`, version, id)
+	if err != nil {
+		return err
+	}
+
+	childVersion := version
+	if !personal {
+		if childVersion, err = nextVersion(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	for _, tombstone := range []struct {
+		query   string
+		version int
+	}{
+		{`UPDATE comments SET deleted = true, body = '', version = $1, updated_at = now() WHERE item_id = $2 AND deleted = false`, version},
+		{`UPDATE attachments SET deleted = true, version = $1, updated_at = now() WHERE item_id = $2 AND deleted = false`, childVersion},
+		{`UPDATE item_tags SET deleted = true, version = $1, updated_at = now() WHERE item_id = $2 AND deleted = false`, childVersion},
+	} {
+		if _, err := tx.ExecContext(ctx, tombstone.query, tombstone.version, id); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM item_links WHERE source_id = $1`, id)
+	return err
+}
+
+/*
+Reorder moves an item within its list by rewriting only its own key.
+
+Concurrent reorders from two devices are ordinary versioned mutations:
+the second one conflicts and is rebased on the server copy. Equal keys
+(two devices inserting between the same neighbours) are tie-broken by
+id, so every device converges on the same order.
+*/
+func (r *ItemRepository) Reorder(ctx context.Context, move repository.ItemMove, mutationID string) (*domain.Item, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if appliedVersion, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(
+			ctx,
+			"mutation replayed (reorder)",
+			"item_id", move.ID,
+			"applied_version", appliedVersion,
 		)
-		VALUES ($1, $2, 'delete', $3)
-		`,
-		mutationID,
-		id,
-		newVersion,
+
+		current, _, err := accessibleItemTx(ctx, tx, move.UserID, move.ID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = appliedVersion
+		return current, nil
+	}
+
+	// 2️⃣ Load current state; lists of personal items belong to the
+	// owner, team lists to anyone who may edit the workspace
+	current, role, err := accessibleItemTx(ctx, tx, move.UserID, move.ID)
+	if err != nil {
+		return nil, err
+	}
+	if role == domain.RoleViewer || (role != domain.RoleOwner && current.InPersonalWorkspace()) {
+		return nil, domain.ErrForbidden
+	}
+
+	if current.Version != move.Version {
+		middleware.LogWithContext(
+			ctx,
+			"version conflict (reorder)",
+			"item_id", move.ID,
+			"client_version", move.Version,
+			"server_version", current.Version,
+		)
+
+		return nil, domain.NewConflictError(current)
+	}
+	if current.Deleted {
+		return nil, domain.ErrNotFound
+	}
+
+	// 3️⃣ Resolve the new key
+	position := move.Position
+	if position == "" {
+		position, err = positionNextToTx(ctx, tx, current, move)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 4️⃣ Allocate the workspace version (global for personal workspaces)
+	newVersion, err := nextWorkspaceVersion(ctx, tx, current.WorkspaceID, current.Personal)
+	if err != nil {
+		return nil, err
+	}
+
+	middleware.LogWithContext(
+		ctx,
+		"version allocated (reorder)",
+		"item_id", move.ID,
+		"position", position,
+		"new_version", newVersion,
 	)
+
+	// 5️⃣ Rewrite the item's key only
+	_, err = tx.ExecContext(ctx, `
+		UPDATE items
+		SET position = $1, version = $2, updated_at = now()
+		WHERE id = $3
+	`, position, newVersion, move.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(position) > ordering.RebalanceLength {
+		if err := rebalanceTx(ctx, tx, current.WorkspaceID, current.CollectionID, newVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       move.UserID,
+		EntityType:   "item",
+		EntityID:     move.ID,
+		MutationType: "reorder",
+		Version:      newVersion,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	deletedItem, err := r.GetByIdTx(ctx, tx, userID, id)
+	moved, err := r.GetByIdTx(ctx, tx, current.UserID, move.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	return deletedItem, tx.Commit()
+	return moved, tx.Commit()
 }
 
+/*
+positionNextToTx derives a key directly after move.AfterID or directly
+before move.BeforeID. The anchor must be a placed, live item of the
+same list; items sharing the anchor's key stay on its side.
+*/
+func positionNextToTx(ctx context.Context, tx *sql.Tx, item *domain.Item, move repository.ItemMove) (string, error) {
+	field, anchorID := "after_id", move.AfterID
+	if anchorID == "" {
+		field, anchorID = "before_id", move.BeforeID
+	}
+
+	var anchor string
+	err := tx.QueryRowContext(ctx, `
+		SELECT position FROM items
+		WHERE id = $1 AND id <> $2
+		AND workspace_id = $3 AND collection_id IS NOT DISTINCT FROM $4
+		AND position <> '' AND deleted = false
+	`, anchorID, item.ID, item.WorkspaceID, nullableID(item.CollectionID)).Scan(&anchor)
+	if err == sql.ErrNoRows {
+		return "", domain.NewValidationError(domain.FieldError{
+			Field:   field,
+			Message: "must be a placed item in the same list",
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// the neighbour on the other side of the anchor ("" at either end)
+	query := `
+		SELECT COALESCE(MIN(position), '') FROM items
+		WHERE position > $1`
+	if field == "before_id" {
+		query = `
+		SELECT COALESCE(MAX(position), '') FROM items
+		WHERE position < $1 AND position <> ''`
+	}
+	var other string
+	err = tx.QueryRowContext(ctx, query+`
+		AND id <> $2
+		AND workspace_id = $3 AND collection_id IS NOT DISTINCT FROM $4
+		AND deleted = false
+	`, anchor, item.ID, item.WorkspaceID, nullableID(item.CollectionID)).Scan(&other)
+	if err != nil {
+		return "", err
+	}
+
+	if field == "before_id" {
+		return ordering.Between(other, anchor)
+	}
+	return ordering.Between(anchor, other)
+}
+
+/*
+rebalanceTx replaces every key of one list with short, evenly spaced
+keys, preserving the current order. It runs when a key outgrows
+ordering.RebalanceLength; the rewritten items take the triggering
+mutation's version so other devices pick up the new keys from /changes.
+*/
+func rebalanceTx(ctx context.Context, tx *sql.Tx, workspaceID string, collectionID *string, version int) error {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id FROM items
+		WHERE workspace_id = $1 AND collection_id IS NOT DISTINCT FROM $2
+		AND position <> '' AND deleted = false
+		ORDER BY position, id
+		FOR UPDATE
+	`, workspaceID, nullableID(collectionID))
+	if err != nil {
+		return err
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	middleware.LogWithContext(ctx, "rebalancing list",
+		"workspace_id", workspaceID,
+		"items", len(ids),
+		"version", version,
+	)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE items
+		SET position = k.position, version = $1
+		FROM unnest($2::uuid[], $3::text[]) AS k(id, position)
+		WHERE items.id = k.id
+	`, version, ids, ordering.Spread(len(ids)))
+	return err
+}
+
+/*
+GetChanges returns the changed items of the user's personal workspace
+plus items shared with them that changed, or whose share was granted
+or changed, after sinceVersion. Shared items keep their own global
+version. Team workspaces are synced by GetWorkspaceChanges.
+*/
 func (r *ItemRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Item, int, error) {
 	query := `
-		SELECT id, user_id, type, title, content, version, deleted, created_at, updated_at
+		SELECT ` + itemColumns + `
 		FROM items
-		WHERE user_id = $1
-		And version > $2
+		WHERE (personal AND workspace_id = $1 AND version > $2)
+		OR EXISTS (
+			SELECT 1 FROM item_shares s
+			WHERE s.item_id = items.id
+			AND s.user_id = $1
+			AND s.revoked = false
+			AND (items.version > $2 OR s.version > $2)
+		)
 		ORDER BY version ASC
 	`
 
@@ -428,18 +1209,8 @@ func (r *ItemRepository) GetChanges(ctx context.Context, userID string, sinceVer
 	)
 
 	for rows.Next() {
-		item := &domain.Item{}
-		if err := rows.Scan(
-			&item.ID,
-			&item.UserID,
-			&item.Type,
-			&item.Title,
-			&item.Content,
-			&item.Version,
-			&item.Deleted,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		); err != nil {
+		item, err := scanItem(rows)
+		if err != nil {
 			return nil, 0, err
 		}
 
@@ -449,25 +1220,144 @@ func (r *ItemRepository) GetChanges(ctx context.Context, userID string, sinceVer
 
 		items = append(items, item)
 	}
-	return items, latestVersion, nil
+	return items, latestVersion, rows.Err()
 }
 
-func (r *ItemRepository) getAppliedVersion(ctx context.Context, tx *sql.Tx, mutationID string) (int, bool, error) {
-	var v int
-	err := tx.QueryRowContext(
-		ctx,
-		`SELECT applied_version 
-		FROM mutation_log 
-		WHERE mutation_id = $1`,
-		mutationID,
-	).Scan(&v)
+// ListByCreator feeds the account export, so tombstones are kept.
+func (r *ItemRepository) ListByCreator(ctx context.Context, userID string) ([]*domain.Item, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE user_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if err == sql.ErrNoRows {
-		return 0, false, nil
+	items := []*domain.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// GetWorkspaceChanges returns items of a team workspace whose
+// per-workspace version is greater than sinceVersion.
+func (r *ItemRepository) GetWorkspaceChanges(ctx context.Context, workspaceID string, sinceVersion int) ([]*domain.Item, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE workspace_id = $1 AND NOT personal
+		AND version > $2
+		ORDER BY version ASC
+	`, workspaceID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		items         []*domain.Item
+		latestVersion = sinceVersion
+	)
+
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		latestVersion = max(latestVersion, item.Version)
+		items = append(items, item)
+	}
+	return items, latestVersion, rows.Err()
+}
+
+const (
+	searchConfig = "english"
+
+	titleHeadline   = `StartSel=<mark>, StopSel=</mark>, HighlightAll=true`
+	contentHeadline = `StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=5, FragmentDelimiter=" … "`
+)
+
+/*
+Search ranks items against the generated search_vector column.
+
+Title matches weigh more than content matches (weights A / B).
+Soft-deleted items are excluded unless q.IncludeDeleted is set.
+Encrypted items have an empty search_vector and never match.
+*/
+func (r *ItemRepository) Search(ctx context.Context, userID string, q repository.ItemSearchQuery) ([]*domain.SearchResult, error) {
+	where := []string{visibleTo, "search_vector @@ q.query"}
+	args := []any{userID, q.Query}
+
+	if !q.IncludeDeleted {
+		where = append(where, "deleted = false")
+	}
+
+	if len(q.Types) > 0 {
+		args = append(args, q.Types)
+		where = append(where, fmt.Sprintf("type = ANY($%d)", len(args)))
 	}
+
+	args = append(args, q.Limit)
+
+	query := `
+		WITH q AS (SELECT websearch_to_tsquery('` + searchConfig + `', $2) AS query)
+		SELECT ` + itemColumns + `,
+			ts_rank(search_vector, q.query) AS rank,
+			ts_headline('` + searchConfig + `', title, q.query, '` + titleHeadline + `'),
+			ts_headline('` + searchConfig + `', content, q.query, '` + contentHeadline + `')
+		FROM items, q
+		WHERE ` + strings.Join(where, " AND ") + `
+		ORDER BY rank DESC, updated_at DESC, id ASC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return 0, false, err
+		return nil, err
 	}
-	return v, true, nil
+	defer rows.Close()
+
+	results := []*domain.SearchResult{}
+	for rows.Next() {
+		var (
+			res      domain.SearchResult
+			extra    searchColumns
+			scanning = searchRow{rows: rows, extra: &extra}
+		)
+
+		item, err := scanItem(scanning)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Item = item
+		res.Rank = extra.rank
+		res.TitleSnippet = extra.titleSnippet
+		res.ContentSnippet = extra.contentSnippet
+		results = append(results, &res)
+	}
+	return results, rows.Err()
+}
+
+type searchColumns struct {
+	rank           float64
+	titleSnippet   string
+	contentSnippet string
+}
+
+// searchRow appends the ranking columns to the standard item scan.
+type searchRow struct {
+	rows  *sql.Rows
+	extra *searchColumns
+}
 
+func (s searchRow) Scan(dest ...any) error {
+	return s.rows.Scan(append(dest, &s.extra.rank, &s.extra.titleSnippet, &s.extra.contentSnippet)...)
 }
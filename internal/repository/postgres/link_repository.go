@@ -0,0 +1,177 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/links"
+	"context"
+	"database/sql"
+)
+
+type LinkRepository struct {
+	db *sql.DB
+}
+
+func NewLinkRepository(db *sql.DB) *LinkRepository {
+	return &LinkRepository{db: db}
+}
+
+/*
+replaceLinksTx rebuilds the outgoing links of one item from its content.
+
+Called by ItemRepository inside the mutation transaction so the link
+index can never disagree with the committed content. Encrypted items
+have no readable content and therefore no outgoing links; links are
+personal, so team workspace items have none either.
+*/
+func replaceLinksTx(ctx context.Context, tx *sql.Tx, item *domain.Item) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM item_links WHERE source_id = $1`, item.ID); err != nil {
+		return err
+	}
+	if item.Encrypted() || !item.InPersonalWorkspace() {
+		return nil
+	}
+
+	for _, ref := range links.Parse(item.Content) {
+		// prefer an exact ID match, then the oldest live item with that title
+		var targetID sql.NullString
+		err := tx.QueryRowContext(ctx, `
+			SELECT id
+			FROM items
+			WHERE workspace_id = $1 AND personal
+			AND (id::text = $2 OR (encryption_key_id IS NULL AND lower(title) = lower($2)))
+			ORDER BY (id::text = $2) DESC, deleted ASC, created_at ASC, id ASC
+			LIMIT 1
+		`, item.UserID, ref).Scan(&targetID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO item_links (source_id, user_id, target_ref, target_id)
+			VALUES ($1, $2, $3, $4)
+		`, item.ID, item.UserID, ref, targetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+resolvedLinks resolves each stored link at read time: links that were
+dangling when written are matched by title again, so creating the
+missing note later heals them without rewriting the source item.
+*/
+const resolvedLinks = `
+	SELECT l.source_id, l.target_ref, t.id, COALESCE(t.deleted, true)
+	FROM item_links l
+	JOIN items s ON s.id = l.source_id AND s.deleted = false
+	LEFT JOIN LATERAL (
+		SELECT id, deleted
+		FROM items
+		WHERE workspace_id = l.user_id
+		AND (id = l.target_id OR (l.target_id IS NULL AND encryption_key_id IS NULL AND lower(title) = lower(l.target_ref)))
+		ORDER BY deleted ASC, created_at ASC, id ASC
+		LIMIT 1
+	) t ON true
+	WHERE l.user_id = $1
+`
+
+func scanLink(row rowScanner) (domain.ItemLink, error) {
+	var (
+		link     domain.ItemLink
+		targetID sql.NullString
+	)
+	err := row.Scan(&link.SourceID, &link.TargetRef, &targetID, &link.Dangling)
+	if targetID.Valid {
+		link.TargetID = &targetID.String
+	}
+	return link, err
+}
+
+// Backlinks returns live items linking to itemID. The target itself may be deleted.
+func (r *LinkRepository) Backlinks(ctx context.Context, userID string, itemID string) ([]*domain.Item, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM items WHERE id = $1 AND workspace_id = $2 AND personal)
+	`, itemID, userID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE workspace_id = $1 AND personal
+		AND deleted = false
+		AND id IN (
+			SELECT source_id FROM (`+resolvedLinks+`) resolved
+			WHERE resolved.id = $2
+		)
+		ORDER BY updated_at DESC
+	`, userID, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*domain.Item{}
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// Graph returns all live items of a user and every link between them,
+// including dangling links.
+func (r *LinkRepository) Graph(ctx context.Context, userID string) (*domain.LinkGraph, error) {
+	graph := &domain.LinkGraph{
+		Nodes: []*domain.Item{},
+		Edges: []domain.ItemLink{},
+	}
+
+	nodeRows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemColumns+`
+		FROM items
+		WHERE workspace_id = $1 AND personal AND deleted = false
+		ORDER BY id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer nodeRows.Close()
+
+	for nodeRows.Next() {
+		item, err := scanItem(nodeRows)
+		if err != nil {
+			return nil, err
+		}
+		graph.Nodes = append(graph.Nodes, item)
+	}
+	if err := nodeRows.Err(); err != nil {
+		return nil, err
+	}
+
+	edgeRows, err := r.db.QueryContext(ctx, resolvedLinks+` ORDER BY l.source_id, l.target_ref`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer edgeRows.Close()
+
+	for edgeRows.Next() {
+		link, err := scanLink(edgeRows)
+		if err != nil {
+			return nil, err
+		}
+		graph.Edges = append(graph.Edges, link)
+	}
+	return graph, edgeRows.Err()
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"database/sql"
+)
+
+type MutationLogRepository struct {
+	db *sql.DB
+}
+
+func NewMutationLogRepository(db *sql.DB) *MutationLogRepository {
+	return &MutationLogRepository{db: db}
+}
+
+// rows logged before mutation_log tracked callers have no user_id
+const mutationLogColumns = `mutation_id, COALESCE(user_id::text, ''), entity_type, entity_id, mutation_type, applied_version, created_at`
+
+func (r *MutationLogRepository) list(ctx context.Context, where string, args ...any) ([]*domain.MutationLogEntry, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+mutationLogColumns+`
+		FROM mutation_log
+		WHERE `+where+`
+		ORDER BY created_at ASC, applied_version ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*domain.MutationLogEntry{}
+	for rows.Next() {
+		e := &domain.MutationLogEntry{}
+		err := rows.Scan(&e.MutationID, &e.UserID, &e.EntityType, &e.EntityID, &e.MutationType, &e.Version, &e.AppliedAt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *MutationLogRepository) ListByUser(ctx context.Context, userID string) ([]*domain.MutationLogEntry, error) {
+	return r.list(ctx, `user_id = $1`, userID)
+}
+
+func (r *MutationLogRepository) ListByEntities(ctx context.Context, entityType string, ids []string) ([]*domain.MutationLogEntry, error) {
+	if len(ids) == 0 {
+		return []*domain.MutationLogEntry{}, nil
+	}
+	return r.list(ctx, `entity_type = $1 AND entity_id = ANY($2::uuid[])`, entityType, ids)
+}
@@ -0,0 +1,306 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type ShareRepository struct {
+	db *sql.DB
+}
+
+func NewShareRepository(db *sql.DB) *ShareRepository {
+	return &ShareRepository{db: db}
+}
+
+const shareColumns = `item_id, owner_id, user_id, role, version, revoked, created_at, updated_at`
+
+func scanShare(row rowScanner) (*domain.Share, error) {
+	s := &domain.Share{}
+	err := row.Scan(
+		&s.ItemID,
+		&s.OwnerID,
+		&s.UserID,
+		&s.Role,
+		&s.Version,
+		&s.Revoked,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return s, err
+}
+
+func (r *ShareRepository) getTx(ctx context.Context, tx *sql.Tx, itemID, userID string) (*domain.Share, error) {
+	return scanShare(tx.QueryRowContext(ctx, `
+		SELECT `+shareColumns+`
+		FROM item_shares
+		WHERE item_id = $1 AND user_id = $2
+	`, itemID, userID))
+}
+
+/*
+itemRoleTx returns the caller's role on a personal item and whether the
+item is deleted. Items the caller cannot see are reported as not found
+so their existence does not leak. Team workspace items are shared
+through membership, never through item shares.
+*/
+func itemRoleTx(ctx context.Context, tx *sql.Tx, callerID, itemID string) (string, bool, error) {
+	var (
+		role    sql.NullString
+		deleted bool
+	)
+	err := tx.QueryRowContext(ctx, `
+		SELECT
+			CASE WHEN i.workspace_id = $2 THEN 'owner' ELSE s.role END,
+			i.deleted
+		FROM items i
+		LEFT JOIN item_shares s ON s.item_id = i.id AND s.user_id = $2 AND s.revoked = false
+		WHERE i.id = $1 AND i.personal
+	`, itemID, callerID).Scan(&role, &deleted)
+	if err == sql.ErrNoRows || (err == nil && !role.Valid) {
+		return "", false, domain.ErrNotFound
+	}
+	return role.String, deleted, err
+}
+
+func (r *ShareRepository) Share(ctx context.Context, share *domain.Share, mutationID string) (*domain.Share, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (share)",
+			"item_id", share.ItemID,
+			"collaborator_id", share.UserID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getTx(ctx, tx, share.ItemID, share.UserID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Only the owner of a live item may share it
+	role, deleted, err := itemRoleTx(ctx, tx, share.OwnerID, share.ItemID)
+	if err != nil {
+		return nil, err
+	}
+	if deleted {
+		return nil, domain.ErrNotFound
+	}
+	if role != domain.RoleOwner {
+		return nil, domain.ErrForbidden
+	}
+
+	// 3️⃣ Version check against the share row (0 = never shared)
+	current, err := r.getTx(ctx, tx, share.ItemID, share.UserID)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	serverVersion := 0
+	if current != nil {
+		serverVersion = current.Version
+	}
+	if serverVersion != share.Version {
+		middleware.LogWithContext(ctx, "version conflict (share)",
+			"item_id", share.ItemID,
+			"collaborator_id", share.UserID,
+			"client_version", share.Version,
+			"server_version", serverVersion,
+		)
+		if current == nil {
+			return nil, domain.ErrNotFound
+		}
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 4️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Upsert share (re-sharing clears a revocation)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO item_shares (item_id, user_id, owner_id, role, version, revoked, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, false, now(), now())
+		ON CONFLICT (item_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role, version = EXCLUDED.version, revoked = false, updated_at = now()
+	`, share.ItemID, share.UserID, share.OwnerID, share.Role, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       share.OwnerID,
+		EntityType:   "item_share",
+		EntityID:     share.ItemID,
+		MutationType: "share",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shared, err := r.getTx(ctx, tx, share.ItemID, share.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return shared, tx.Commit()
+}
+
+func (r *ShareRepository) Unshare(ctx context.Context, itemID string, collaboratorID string, callerID string, version int, mutationID string) (*domain.Share, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (unshare)",
+			"item_id", itemID,
+			"collaborator_id", collaboratorID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getTx(ctx, tx, itemID, collaboratorID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state; the owner revokes, a collaborator may leave
+	current, err := r.getTx(ctx, tx, itemID, collaboratorID)
+	if err != nil {
+		return nil, err
+	}
+	if callerID != current.OwnerID && callerID != current.UserID {
+		return nil, domain.ErrNotFound
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (unshare)",
+			"item_id", itemID,
+			"collaborator_id", collaboratorID,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone share; the collaborator receives it as access_revoked
+	_, err = tx.ExecContext(ctx, `
+		UPDATE item_shares
+		SET revoked = true, version = $1, updated_at = now()
+		WHERE item_id = $2 AND user_id = $3
+	`, newVersion, itemID, collaboratorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "item_share",
+		EntityID:     itemID,
+		MutationType: "unshare",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := r.getTx(ctx, tx, itemID, collaboratorID)
+	if err != nil {
+		return nil, err
+	}
+	return revoked, tx.Commit()
+}
+
+// ListByItem returns the active shares of an item the caller can access.
+func (r *ShareRepository) ListByItem(ctx context.Context, callerID string, itemID string) ([]*domain.Share, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+shareColumns+`
+		FROM item_shares
+		WHERE item_id = $1
+		AND revoked = false
+		AND (
+			owner_id = $2
+			OR EXISTS (
+				SELECT 1 FROM item_shares me
+				WHERE me.item_id = $1 AND me.user_id = $2 AND me.revoked = false
+			)
+		)
+		ORDER BY created_at ASC, user_id ASC
+	`, itemID, callerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*domain.Share{}
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, s)
+	}
+	return shares, rows.Err()
+}
+
+func (r *ShareRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Share, int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+shareColumns+`
+		FROM item_shares
+		WHERE (owner_id = $1 OR user_id = $1)
+		AND version > $2
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var (
+		shares        []*domain.Share
+		latestVersion = sinceVersion
+	)
+
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		latestVersion = max(latestVersion, s.Version)
+		shares = append(shares, s)
+	}
+	return shares, latestVersion, rows.Err()
+}
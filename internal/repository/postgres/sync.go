@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+	"database/sql"
+)
+
+/*
+Shared building blocks for every versioned mutation.
+
+All of them take the mutation transaction: the version allocation,
+the entity write and the mutation_log row must commit together.
+*/
+
+// nextVersion allocates the next global version.
+func nextVersion(ctx context.Context, tx *sql.Tx) (int, error) {
+	var v int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE sync_state
+		SET latest_version = latest_version + 1
+		WHERE id = 1
+		RETURNING latest_version
+	`).Scan(&v)
+
+	return v, err
+}
+
+/*
+committedVersion reads the counter nextWorkspaceVersion would draw from,
+without allocating. Allocation holds the counter row lock until commit,
+so every version at or below the result is already committed: a feed
+bounded by it cannot skip a write that commits later.
+*/
+func committedVersion(ctx context.Context, q queryer, workspaceID string) (int, error) {
+	var v int
+	if workspaceID != "" {
+		err := q.QueryRowContext(ctx, `
+			SELECT latest_version FROM workspaces WHERE id = $1
+		`, workspaceID).Scan(&v)
+		if err != sql.ErrNoRows {
+			return v, err
+		}
+	}
+
+	err := q.QueryRowContext(ctx, `
+		SELECT latest_version FROM sync_state WHERE id = 1
+	`).Scan(&v)
+
+	return v, err
+}
+
+/*
+nextWorkspaceVersion allocates the version of a write to an item in
+workspaceID. Team workspaces have their own counter (the row lock also
+serialises writes per workspace); personal workspaces use the global
+counter, whatever rows exist.
+*/
+func nextWorkspaceVersion(ctx context.Context, tx *sql.Tx, workspaceID string, personal bool) (int, error) {
+	if personal {
+		return nextVersion(ctx, tx)
+	}
+
+	var v int
+	err := tx.QueryRowContext(ctx, `
+		UPDATE workspaces
+		SET latest_version = latest_version + 1
+		WHERE id = $1
+		RETURNING latest_version
+	`, workspaceID).Scan(&v)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrNotFound
+	}
+	return v, err
+}
+
+// appliedVersion reports whether mutationID was already applied.
+func appliedVersion(ctx context.Context, tx *sql.Tx, mutationID string) (int, bool, error) {
+	var v int
+	err := tx.QueryRowContext(
+		ctx,
+		`SELECT applied_version 
+		FROM mutation_log 
+		WHERE mutation_id = $1`,
+		mutationID,
+	).Scan(&v)
+
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return v, true, nil
+}
+
+type mutationRecord struct {
+	MutationID   string
+	UserID       string
+	EntityType   string // item | tag | item_tag | ...
+	EntityID     string
+	MutationType string // create | update | delete | ...
+	Version      int
+}
+
+// permissionEntities are the entities whose mutations change who can access what.
+var permissionEntities = map[string]bool{
+	"item_share":       true,
+	"workspace_member": true,
+}
+
+/*
+recordMutation makes the mutation idempotent for later replays and
+appends it to the audit log, in the same transaction so the two can
+never disagree.
+*/
+func recordMutation(ctx context.Context, tx *sql.Tx, m mutationRecord) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`
+		INSERT INTO mutation_log (
+			mutation_id,
+			user_id,
+			entity_type,
+			entity_id,
+			mutation_type,
+			applied_version
+		)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		`,
+		m.MutationID,
+		m.UserID,
+		m.EntityType,
+		m.EntityID,
+		m.MutationType,
+		m.Version,
+	)
+	if err != nil {
+		return err
+	}
+
+	category := domain.AuditMutation
+	if permissionEntities[m.EntityType] {
+		category = domain.AuditPermission
+	}
+	e := auditEvent(ctx, m.UserID, category, m.MutationType)
+	e.EntityType, e.EntityID = m.EntityType, m.EntityID
+	e.MutationID, e.Version = m.MutationID, m.Version
+	return appendAuditTx(ctx, tx, e)
+}
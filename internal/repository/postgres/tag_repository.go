@@ -0,0 +1,559 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type TagRepository struct {
+	db *sql.DB
+}
+
+func NewTagRepository(db *sql.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+const tagColumns = `id, user_id, name, version, deleted, created_at, updated_at`
+
+const itemTagColumns = `item_id, tag_id, user_id, version, deleted, created_at, updated_at`
+
+func scanTag(row rowScanner) (*domain.Tag, error) {
+	tag := &domain.Tag{}
+	err := row.Scan(
+		&tag.ID,
+		&tag.UserID,
+		&tag.Name,
+		&tag.Version,
+		&tag.Deleted,
+		&tag.CreatedAt,
+		&tag.UpdatedAt,
+	)
+	return tag, err
+}
+
+func scanItemTag(row rowScanner) (*domain.ItemTag, error) {
+	it := &domain.ItemTag{}
+	err := row.Scan(
+		&it.ItemID,
+		&it.TagID,
+		&it.UserID,
+		&it.Version,
+		&it.Deleted,
+		&it.CreatedAt,
+		&it.UpdatedAt,
+	)
+	return it, err
+}
+
+func (r *TagRepository) getTagTx(ctx context.Context, tx *sql.Tx, userID, id string) (*domain.Tag, error) {
+	tag, err := scanTag(tx.QueryRowContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags
+		WHERE id = $1 AND user_id = $2
+	`, id, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return tag, err
+}
+
+func (r *TagRepository) getItemTagTx(ctx context.Context, tx *sql.Tx, userID, itemID, tagID string) (*domain.ItemTag, error) {
+	it, err := scanItemTag(tx.QueryRowContext(ctx, `
+		SELECT `+itemTagColumns+`
+		FROM item_tags
+		WHERE item_id = $1 AND tag_id = $2 AND user_id = $3
+	`, itemID, tagID, userID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return it, err
+}
+
+func (r *TagRepository) Create(ctx context.Context, tag *domain.Tag, mutationID string) (*domain.Tag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (tag create)", "tag_id", tag.ID, "applied_version", applied)
+
+		existing, err := r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+		if err != nil {
+			return nil, err
+		}
+		existing.Version = applied
+		return existing, nil
+	}
+
+	// 2️⃣ Ensure tag does not already exist
+	_, err = r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+	if err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	// 3️⃣ Allocate global version
+	version, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Insert
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO tags (id, user_id, name, version, deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, false, now(), now())
+	`, tag.ID, tag.UserID, tag.Name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       tag.UserID,
+		EntityType:   "tag",
+		EntityID:     tag.ID,
+		MutationType: "create",
+		Version:      version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *TagRepository) ListByUser(ctx context.Context, userID string) ([]*domain.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags
+		WHERE user_id = $1 AND deleted = false
+		ORDER BY name ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*domain.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// Rename also resurrects a deleted tag, mirroring item updates.
+func (r *TagRepository) Rename(ctx context.Context, tag *domain.Tag, mutationID string) (*domain.Tag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (tag rename)", "tag_id", tag.ID, "applied_version", applied)
+
+		current, err := r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state
+	current, err := r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != tag.Version {
+		middleware.LogWithContext(ctx, "version conflict (tag rename)",
+			"tag_id", tag.ID,
+			"client_version", tag.Version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Apply rename
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tags
+		SET name = $1, version = $2, deleted = false, updated_at = now()
+		WHERE id = $3 AND user_id = $4
+	`, tag.Name, newVersion, tag.ID, tag.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       tag.UserID,
+		EntityType:   "tag",
+		EntityID:     tag.ID,
+		MutationType: "rename",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	renamed, err := r.getTagTx(ctx, tx, tag.UserID, tag.ID)
+	if err != nil {
+		return nil, err
+	}
+	return renamed, tx.Commit()
+}
+
+/*
+SoftDelete tombstones the tag and every live assignment of it.
+
+The tag and its assignments share the mutation's version, so a device
+that syncs past it sees both in the same /changes response.
+*/
+func (r *TagRepository) SoftDelete(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Tag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (tag delete)", "tag_id", id, "applied_version", applied)
+
+		current, err := r.getTagTx(ctx, tx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state
+	current, err := r.getTagTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (tag delete)",
+			"tag_id", id,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone tag and its assignments
+	_, err = tx.ExecContext(ctx, `
+		UPDATE tags
+		SET deleted = true, version = $1, updated_at = now()
+		WHERE id = $2 AND user_id = $3
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE item_tags
+		SET deleted = true, version = $1, updated_at = now()
+		WHERE tag_id = $2 AND user_id = $3 AND deleted = false
+	`, newVersion, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "tag",
+		EntityID:     id,
+		MutationType: "delete",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deleted, err := r.getTagTx(ctx, tx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return deleted, tx.Commit()
+}
+
+func (r *TagRepository) Assign(ctx context.Context, assignment *domain.ItemTag, mutationID string) (*domain.ItemTag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userID := assignment.UserID
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (tag assign)",
+			"item_id", assignment.ItemID,
+			"tag_id", assignment.TagID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getItemTagTx(ctx, tx, userID, assignment.ItemID, assignment.TagID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Both sides must exist and be live
+	if err := r.requireLiveItemTx(ctx, tx, userID, assignment.ItemID); err != nil {
+		return nil, err
+	}
+	tag, err := r.getTagTx(ctx, tx, userID, assignment.TagID)
+	if err != nil {
+		return nil, err
+	}
+	if tag.Deleted {
+		return nil, domain.NewEntityConflictError(tag)
+	}
+
+	// 3️⃣ Version check against the assignment row (0 = never assigned)
+	current, err := r.getItemTagTx(ctx, tx, userID, assignment.ItemID, assignment.TagID)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	serverVersion := 0
+	if current != nil {
+		serverVersion = current.Version
+	}
+	if serverVersion != assignment.Version {
+		middleware.LogWithContext(ctx, "version conflict (tag assign)",
+			"item_id", assignment.ItemID,
+			"tag_id", assignment.TagID,
+			"client_version", assignment.Version,
+			"server_version", serverVersion,
+		)
+		if current == nil {
+			return nil, domain.ErrNotFound
+		}
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 4️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Upsert assignment
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO item_tags (item_id, tag_id, user_id, version, deleted, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, false, now(), now())
+		ON CONFLICT (item_id, tag_id)
+		DO UPDATE SET version = EXCLUDED.version, deleted = false, updated_at = now()
+	`, assignment.ItemID, assignment.TagID, userID, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "item_tag",
+		EntityID:     assignment.ItemID,
+		MutationType: "assign",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	assigned, err := r.getItemTagTx(ctx, tx, userID, assignment.ItemID, assignment.TagID)
+	if err != nil {
+		return nil, err
+	}
+	return assigned, tx.Commit()
+}
+
+func (r *TagRepository) Unassign(ctx context.Context, itemID string, tagID string, userID string, version int, mutationID string) (*domain.ItemTag, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (tag unassign)",
+			"item_id", itemID,
+			"tag_id", tagID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getItemTagTx(ctx, tx, userID, itemID, tagID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Load current state
+	current, err := r.getItemTagTx(ctx, tx, userID, itemID, tagID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (tag unassign)",
+			"item_id", itemID,
+			"tag_id", tagID,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone assignment
+	_, err = tx.ExecContext(ctx, `
+		UPDATE item_tags
+		SET deleted = true, version = $1, updated_at = now()
+		WHERE item_id = $2 AND tag_id = $3 AND user_id = $4
+	`, newVersion, itemID, tagID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       userID,
+		EntityType:   "item_tag",
+		EntityID:     itemID,
+		MutationType: "unassign",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unassigned, err := r.getItemTagTx(ctx, tx, userID, itemID, tagID)
+	if err != nil {
+		return nil, err
+	}
+	return unassigned, tx.Commit()
+}
+
+func (r *TagRepository) requireLiveItemTx(ctx context.Context, tx *sql.Tx, userID, itemID string) error {
+	var deleted bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT deleted FROM items WHERE id = $1 AND workspace_id = $2 AND personal
+	`, itemID, userID).Scan(&deleted)
+	if err == sql.ErrNoRows || (err == nil && deleted) {
+		return domain.ErrNotFound
+	}
+	return err
+}
+
+// GetChanges returns tags and assignments with version > sinceVersion,
+// tombstones included.
+func (r *TagRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Tag, []*domain.ItemTag, int, error) {
+	latestVersion := sinceVersion
+
+	tagRows, err := r.db.QueryContext(ctx, `
+		SELECT `+tagColumns+`
+		FROM tags
+		WHERE user_id = $1 AND version > $2
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer tagRows.Close()
+
+	var tags []*domain.Tag
+	for tagRows.Next() {
+		tag, err := scanTag(tagRows)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		latestVersion = max(latestVersion, tag.Version)
+		tags = append(tags, tag)
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	assignmentRows, err := r.db.QueryContext(ctx, `
+		SELECT `+itemTagColumns+`
+		FROM item_tags
+		WHERE user_id = $1 AND version > $2
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer assignmentRows.Close()
+
+	var assignments []*domain.ItemTag
+	for assignmentRows.Next() {
+		it, err := scanItemTag(assignmentRows)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		latestVersion = max(latestVersion, it.Version)
+		assignments = append(assignments, it)
+	}
+
+	return tags, assignments, latestVersion, assignmentRows.Err()
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type UsageRepository struct {
+	db *sql.DB
+}
+
+func NewUsageRepository(db *sql.DB) *UsageRepository {
+	return &UsageRepository{db: db}
+}
+
+func (r *UsageRepository) Get(ctx context.Context, userID string) (*domain.Usage, error) {
+	u := &domain.Usage{UserID: userID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT item_count, content_bytes, attachment_bytes, updated_at
+		FROM user_usage
+		WHERE user_id = $1
+	`, userID).Scan(&u.ItemCount, &u.ContentBytes, &u.AttachmentBytes, &u.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return u, nil
+	}
+	return u, err
+}
+
+/*
+chargeUsageTx adds delta to userID's usage inside the mutation
+transaction and fails with QuotaExceededError when a resource that grew
+ends up over quota; the caller's rollback then undoes the charge. The
+row lock serialises the owner's concurrent charges, so two devices
+cannot both squeeze under the limit.
+*/
+func chargeUsageTx(ctx context.Context, tx *sql.Tx, userID string, quota domain.Quota, delta domain.Usage) error {
+	if delta.ItemCount == 0 && delta.ContentBytes == 0 && delta.AttachmentBytes == 0 {
+		return nil
+	}
+
+	u := &domain.Usage{UserID: userID}
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO user_usage (user_id, item_count, content_bytes, attachment_bytes, updated_at)
+		VALUES ($1, $2, $3, $4, now())
+		ON CONFLICT (user_id) DO UPDATE SET
+			item_count = user_usage.item_count + EXCLUDED.item_count,
+			content_bytes = user_usage.content_bytes + EXCLUDED.content_bytes,
+			attachment_bytes = user_usage.attachment_bytes + EXCLUDED.attachment_bytes,
+			updated_at = now()
+		RETURNING item_count, content_bytes, attachment_bytes
+	`, userID, delta.ItemCount, delta.ContentBytes, delta.AttachmentBytes).Scan(&u.ItemCount, &u.ContentBytes, &u.AttachmentBytes)
+	if err != nil {
+		return err
+	}
+
+	if resource, limit, over := quota.Exceeded(u, delta); over {
+		middleware.LogWithContext(ctx, "quota exceeded", "owner_id", userID, "resource", resource, "limit", limit)
+		return &domain.QuotaExceededError{Resource: resource, Limit: limit}
+	}
+	return nil
+}
+
+// contentBytes is what an item's title and content count against the quota.
+func contentBytes(title, content string) int64 {
+	return int64(len(title) + len(content))
+}
@@ -0,0 +1,539 @@
+package postgres
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/http/middleware"
+	"context"
+	"database/sql"
+)
+
+type WorkspaceRepository struct {
+	db *sql.DB
+}
+
+func NewWorkspaceRepository(db *sql.DB) *WorkspaceRepository {
+	return &WorkspaceRepository{db: db}
+}
+
+const workspaceColumns = `id, owner_id, name, version, created_at, updated_at`
+
+const memberColumns = `workspace_id, user_id, role, version, removed, created_at, updated_at`
+
+func scanWorkspace(row rowScanner) (*domain.Workspace, error) {
+	ws := &domain.Workspace{}
+	err := row.Scan(&ws.ID, &ws.OwnerID, &ws.Name, &ws.Version, &ws.CreatedAt, &ws.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return ws, err
+}
+
+func scanMember(row rowScanner) (*domain.WorkspaceMember, error) {
+	m := &domain.WorkspaceMember{}
+	err := row.Scan(&m.WorkspaceID, &m.UserID, &m.Role, &m.Version, &m.Removed, &m.CreatedAt, &m.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrNotFound
+	}
+	return m, err
+}
+
+func (r *WorkspaceRepository) getTx(ctx context.Context, tx *sql.Tx, id string) (*domain.Workspace, error) {
+	return scanWorkspace(tx.QueryRowContext(ctx, `
+		SELECT `+workspaceColumns+` FROM workspaces WHERE id = $1
+	`, id))
+}
+
+func (r *WorkspaceRepository) getMemberTx(ctx context.Context, tx *sql.Tx, workspaceID, userID string) (*domain.WorkspaceMember, error) {
+	return scanMember(tx.QueryRowContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM workspace_members
+		WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, userID))
+}
+
+func (r *WorkspaceRepository) Create(ctx context.Context, ws *domain.Workspace, mutationID string) (*domain.Workspace, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		// the replay carries a fresh server ID; the first one is logged
+		var id string
+		err := tx.QueryRowContext(ctx, `
+			SELECT entity_id::text FROM mutation_log WHERE mutation_id = $1
+		`, mutationID).Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		middleware.LogWithContext(ctx, "mutation replayed (workspace create)", "workspace_id", id, "applied_version", applied)
+
+		existing, err := r.getTx(ctx, tx, id)
+		if err != nil {
+			return nil, err
+		}
+		existing.Version = applied
+		return existing, nil
+	}
+
+	// 2️⃣ Ensure workspace does not already exist
+	_, err = r.getTx(ctx, tx, ws.ID)
+	if err == nil {
+		return nil, domain.ErrAlreadyExists
+	}
+	if err != domain.ErrNotFound {
+		return nil, err
+	}
+
+	// 3️⃣ Allocate global version
+	version, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Insert workspace and its owner under the same version
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO workspaces (id, owner_id, name, latest_version, version, created_at, updated_at)
+		VALUES ($1, $2, $3, 0, $4, now(), now())
+	`, ws.ID, ws.OwnerID, ws.Name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, version, removed, created_at, updated_at)
+		VALUES ($1, $2, 'owner', $3, false, now(), now())
+	`, ws.ID, ws.OwnerID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       ws.OwnerID,
+		EntityType:   "workspace",
+		EntityID:     ws.ID,
+		MutationType: "create",
+		Version:      version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := r.getTx(ctx, tx, ws.ID)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit()
+}
+
+func (r *WorkspaceRepository) Rename(ctx context.Context, ws *domain.Workspace, callerID string, mutationID string) (*domain.Workspace, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (workspace rename)", "workspace_id", ws.ID, "applied_version", applied)
+
+		current, err := r.getTx(ctx, tx, ws.ID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Only owners may rename
+	role, err := workspaceRoleTx(ctx, tx, ws.ID, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if role != domain.RoleOwner {
+		return nil, domain.ErrForbidden
+	}
+
+	current, err := r.getTx(ctx, tx, ws.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != ws.Version {
+		middleware.LogWithContext(ctx, "version conflict (workspace rename)",
+			"workspace_id", ws.ID,
+			"client_version", ws.Version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Rename
+	_, err = tx.ExecContext(ctx, `
+		UPDATE workspaces
+		SET name = $1, version = $2, updated_at = now()
+		WHERE id = $3
+	`, ws.Name, newVersion, ws.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "workspace",
+		EntityID:     ws.ID,
+		MutationType: "rename",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	renamed, err := r.getTx(ctx, tx, ws.ID)
+	if err != nil {
+		return nil, err
+	}
+	return renamed, tx.Commit()
+}
+
+func (r *WorkspaceRepository) ListForUser(ctx context.Context, userID string) ([]*domain.Workspace, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+workspaceColumns+`
+		FROM workspaces
+		WHERE id IN (
+			SELECT workspace_id FROM workspace_members
+			WHERE user_id = $1 AND removed = false
+		)
+		ORDER BY created_at ASC, id ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	workspaces := []*domain.Workspace{domain.PersonalWorkspace(userID)}
+	for rows.Next() {
+		ws, err := scanWorkspace(rows)
+		if err != nil {
+			return nil, err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	return workspaces, rows.Err()
+}
+
+func (r *WorkspaceRepository) Role(ctx context.Context, workspaceID string, userID string) (string, error) {
+	return workspaceRoleTx(ctx, r.db, workspaceID, userID)
+}
+
+func (r *WorkspaceRepository) SetMember(ctx context.Context, m *domain.WorkspaceMember, callerID string, mutationID string) (*domain.WorkspaceMember, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (member set)",
+			"workspace_id", m.WorkspaceID,
+			"member_id", m.UserID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getMemberTx(ctx, tx, m.WorkspaceID, m.UserID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Only owners manage members; the creator always stays owner
+	role, err := workspaceRoleTx(ctx, tx, m.WorkspaceID, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if role != domain.RoleOwner {
+		return nil, domain.ErrForbidden
+	}
+
+	ws, err := r.getTx(ctx, tx, m.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if m.UserID == ws.OwnerID {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "user_id",
+			Message: "the workspace creator's membership cannot be changed",
+		})
+	}
+
+	// 3️⃣ Version check against the membership row (0 = never a member)
+	current, err := r.getMemberTx(ctx, tx, m.WorkspaceID, m.UserID)
+	if err != nil && err != domain.ErrNotFound {
+		return nil, err
+	}
+	serverVersion := 0
+	if current != nil {
+		serverVersion = current.Version
+	}
+	if serverVersion != m.Version {
+		middleware.LogWithContext(ctx, "version conflict (member set)",
+			"workspace_id", m.WorkspaceID,
+			"member_id", m.UserID,
+			"client_version", m.Version,
+			"server_version", serverVersion,
+		)
+		if current == nil {
+			return nil, domain.ErrNotFound
+		}
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 4️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Upsert membership (re-adding clears a removal)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role, version, removed, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, false, now(), now())
+		ON CONFLICT (workspace_id, user_id)
+		DO UPDATE SET role = EXCLUDED.role, version = EXCLUDED.version, removed = false, updated_at = now()
+	`, m.WorkspaceID, m.UserID, m.Role, newVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "workspace_member",
+		EntityID:     m.WorkspaceID,
+		MutationType: "set_member",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := r.getMemberTx(ctx, tx, m.WorkspaceID, m.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return updated, tx.Commit()
+}
+
+func (r *WorkspaceRepository) RemoveMember(ctx context.Context, workspaceID string, userID string, callerID string, version int, mutationID string) (*domain.WorkspaceMember, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 1️⃣ Idempotency check
+	if applied, ok, err := appliedVersion(ctx, tx, mutationID); err != nil {
+		return nil, err
+	} else if ok {
+		middleware.LogWithContext(ctx, "mutation replayed (member remove)",
+			"workspace_id", workspaceID,
+			"member_id", userID,
+			"applied_version", applied,
+		)
+
+		current, err := r.getMemberTx(ctx, tx, workspaceID, userID)
+		if err != nil {
+			return nil, err
+		}
+		current.Version = applied
+		return current, nil
+	}
+
+	// 2️⃣ Owners remove members, members may leave; the creator stays
+	role, err := workspaceRoleTx(ctx, tx, workspaceID, callerID)
+	if err != nil {
+		return nil, err
+	}
+	if role != domain.RoleOwner && callerID != userID {
+		return nil, domain.ErrForbidden
+	}
+
+	ws, err := r.getTx(ctx, tx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == ws.OwnerID {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "user_id",
+			Message: "the workspace creator cannot be removed",
+		})
+	}
+
+	current, err := r.getMemberTx(ctx, tx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if current.Version != version {
+		middleware.LogWithContext(ctx, "version conflict (member remove)",
+			"workspace_id", workspaceID,
+			"member_id", userID,
+			"client_version", version,
+			"server_version", current.Version,
+		)
+		return nil, domain.NewEntityConflictError(current)
+	}
+
+	// 3️⃣ Allocate global version
+	newVersion, err := nextVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4️⃣ Tombstone membership; the former member's devices receive it
+	_, err = tx.ExecContext(ctx, `
+		UPDATE workspace_members
+		SET removed = true, version = $1, updated_at = now()
+		WHERE workspace_id = $2 AND user_id = $3
+	`, newVersion, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 5️⃣ Record mutation
+	err = recordMutation(ctx, tx, mutationRecord{
+		MutationID:   mutationID,
+		UserID:       callerID,
+		EntityType:   "workspace_member",
+		EntityID:     workspaceID,
+		MutationType: "remove_member",
+		Version:      newVersion,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := r.getMemberTx(ctx, tx, workspaceID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return removed, tx.Commit()
+}
+
+func (r *WorkspaceRepository) ListMembers(ctx context.Context, workspaceID string, callerID string) ([]*domain.WorkspaceMember, error) {
+	if _, err := r.Role(ctx, workspaceID, callerID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM workspace_members
+		WHERE workspace_id = $1 AND removed = false
+		ORDER BY created_at ASC, user_id ASC
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []*domain.WorkspaceMember{}
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+/*
+GetChanges returns, for every workspace the user belongs to, the
+workspace row and its memberships when they changed or the user joined
+after sinceVersion, plus the user's own removals so former members
+learn to drop the workspace.
+*/
+func (r *WorkspaceRepository) GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Workspace, []*domain.WorkspaceMember, int, error) {
+	latestVersion := sinceVersion
+
+	wsRows, err := r.db.QueryContext(ctx, `
+		SELECT `+workspaceColumns+`
+		FROM workspaces w
+		WHERE EXISTS (
+			SELECT 1 FROM workspace_members me
+			WHERE me.workspace_id = w.id
+			AND me.user_id = $1
+			AND me.removed = false
+			AND (w.version > $2 OR me.version > $2)
+		)
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer wsRows.Close()
+
+	var workspaces []*domain.Workspace
+	for wsRows.Next() {
+		ws, err := scanWorkspace(wsRows)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		latestVersion = max(latestVersion, ws.Version)
+		workspaces = append(workspaces, ws)
+	}
+	if err := wsRows.Err(); err != nil {
+		return nil, nil, 0, err
+	}
+
+	memberRows, err := r.db.QueryContext(ctx, `
+		SELECT `+memberColumns+`
+		FROM workspace_members m
+		WHERE (m.user_id = $1 AND m.version > $2)
+		OR EXISTS (
+			SELECT 1 FROM workspace_members me
+			WHERE me.workspace_id = m.workspace_id
+			AND me.user_id = $1
+			AND me.removed = false
+			AND (m.version > $2 OR me.version > $2)
+		)
+		ORDER BY version ASC
+	`, userID, sinceVersion)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer memberRows.Close()
+
+	var members []*domain.WorkspaceMember
+	for memberRows.Next() {
+		m, err := scanMember(memberRows)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		latestVersion = max(latestVersion, m.Version)
+		members = append(members, m)
+	}
+	return workspaces, members, latestVersion, memberRows.Err()
+}
@@ -0,0 +1,19 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type ShareRepository interface {
+	// Share grants or changes a collaborator's role. share.Version is the
+	// client's known share version (0 when never shared with that user).
+	Share(ctx context.Context, share *domain.Share, mutationID string) (*domain.Share, error)
+	// Unshare revokes access; the owner or the collaborator may call it.
+	Unshare(ctx context.Context, itemID string, collaboratorID string, callerID string, version int, mutationID string) (*domain.Share, error)
+	ListByItem(ctx context.Context, callerID string, itemID string) ([]*domain.Share, error)
+
+	// GetChanges returns shares the user owns or is a collaborator on,
+	// revocations included.
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Share, int, error)
+}
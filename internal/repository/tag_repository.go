@@ -0,0 +1,20 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type TagRepository interface {
+	Create(ctx context.Context, tag *domain.Tag, mutationID string) (*domain.Tag, error)
+	ListByUser(ctx context.Context, userID string) ([]*domain.Tag, error)
+	Rename(ctx context.Context, tag *domain.Tag, mutationID string) (*domain.Tag, error)
+	SoftDelete(ctx context.Context, id string, userID string, version int, mutationID string) (*domain.Tag, error)
+
+	// Assign / Unassign take the client's known assignment version
+	// (0 when the item has never carried the tag).
+	Assign(ctx context.Context, assignment *domain.ItemTag, mutationID string) (*domain.ItemTag, error)
+	Unassign(ctx context.Context, itemID string, tagID string, userID string, version int, mutationID string) (*domain.ItemTag, error)
+
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Tag, []*domain.ItemTag, int, error)
+}
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type UsageRepository interface {
+	// Get returns the user's usage; users that never stored anything get zeros.
+	Get(ctx context.Context, userID string) (*domain.Usage, error)
+}
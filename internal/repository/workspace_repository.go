@@ -0,0 +1,28 @@
+package repository
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"context"
+)
+
+type WorkspaceRepository interface {
+	// Create stores a team workspace and makes ws.OwnerID its owner member.
+	Create(ctx context.Context, ws *domain.Workspace, mutationID string) (*domain.Workspace, error)
+	// Rename requires the owner role; ws.Version is the client's base version.
+	Rename(ctx context.Context, ws *domain.Workspace, callerID string, mutationID string) (*domain.Workspace, error)
+	// ListForUser returns the personal workspace followed by team workspaces.
+	ListForUser(ctx context.Context, userID string) ([]*domain.Workspace, error)
+	// Role returns the user's role in a workspace (owner for the personal one).
+	Role(ctx context.Context, workspaceID string, userID string) (string, error)
+
+	// SetMember adds a member or changes a role; m.Version is the client's
+	// known membership version (0 when never a member).
+	SetMember(ctx context.Context, m *domain.WorkspaceMember, callerID string, mutationID string) (*domain.WorkspaceMember, error)
+	// RemoveMember is allowed for owners, and for members leaving.
+	RemoveMember(ctx context.Context, workspaceID string, userID string, callerID string, version int, mutationID string) (*domain.WorkspaceMember, error)
+	ListMembers(ctx context.Context, workspaceID string, callerID string) ([]*domain.WorkspaceMember, error)
+
+	// GetChanges returns workspace and membership changes visible to the
+	// user (global versions), including the user's own removals.
+	GetChanges(ctx context.Context, userID string, sinceVersion int) ([]*domain.Workspace, []*domain.WorkspaceMember, int, error)
+}
@@ -0,0 +1,260 @@
+package validation
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"Offline-First/internal/ordering"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+/*
+Rule describes what a valid item of one type looks like.
+
+Zero values mean "no limit" / "not required".
+*/
+type Rule struct {
+	RequireTitle    bool    `json:"require_title"`
+	RequireContent  bool    `json:"require_content"`
+	MaxTitleLength  int     `json:"max_title_length"`  // characters
+	MaxContentBytes int     `json:"max_content_bytes"` // bytes
+	ContentSchema   *Schema `json:"content_schema"`    // content must be JSON matching this schema
+}
+
+// DefaultRule is applied to types without an explicit rule.
+var DefaultRule = Rule{
+	RequireTitle:    true,
+	MaxTitleLength:  512,
+	MaxContentBytes: 1 << 20,
+}
+
+/*
+Registry holds validation rules keyed by domain.Item.Type.
+
+It is read-only after startup, so it is safe for concurrent use
+by handlers.
+*/
+type Registry struct {
+	defaults          Rule
+	rules             map[string]Rule
+	allowUnknownTypes bool
+}
+
+func NewRegistry(defaults Rule, allowUnknownTypes bool) *Registry {
+	return &Registry{
+		defaults:          defaults,
+		rules:             map[string]Rule{},
+		allowUnknownTypes: allowUnknownTypes,
+	}
+}
+
+func (r *Registry) Register(itemType string, rule Rule) {
+	r.rules[itemType] = rule
+}
+
+func (r *Registry) RejectUnknownTypes() {
+	r.allowUnknownTypes = false
+}
+
+/*
+Config is the on-disk format loaded by LoadRegistry:
+
+	{
+	  "allow_unknown_types": false,
+	  "defaults": { "require_title": true, "max_title_length": 512 },
+	  "types": {
+	    "bookmark": { "content_schema": { "type": "object", "required": ["url"] } }
+	  }
+	}
+*/
+type Config struct {
+	AllowUnknownTypes *bool           `json:"allow_unknown_types"`
+	Defaults          *Rule           `json:"defaults"`
+	Types             map[string]Rule `json:"types"`
+}
+
+func LoadRegistry(path string) (*Registry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(raw, &cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	defaults := DefaultRule
+	if cfg.Defaults != nil {
+		defaults = *cfg.Defaults
+	}
+
+	allowUnknown := true
+	if cfg.AllowUnknownTypes != nil {
+		allowUnknown = *cfg.AllowUnknownTypes
+	}
+
+	if defaults.ContentSchema != nil {
+		if err := defaults.ContentSchema.check("defaults.content_schema"); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	reg := NewRegistry(defaults, allowUnknown)
+	for itemType, rule := range cfg.Types {
+		if rule.ContentSchema != nil {
+			if err := rule.ContentSchema.check("types." + itemType + ".content_schema"); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		reg.Register(itemType, rule)
+	}
+	return reg, nil
+}
+
+/*
+Validate checks an item before any repository call.
+
+All problems are collected so the client can fix every field at once;
+the returned error is a *domain.ValidationError.
+*/
+func (r *Registry) Validate(item *domain.Item) error {
+	verr := domain.NewValidationError()
+
+	if _, err := uuid.Parse(item.ID); err != nil {
+		verr.Add("id", "must be a UUID")
+	}
+
+	if item.CollectionID != nil && *item.CollectionID != "" {
+		if _, err := uuid.Parse(*item.CollectionID); err != nil {
+			verr.Add("collection_id", "must be a UUID")
+		}
+	}
+
+	if item.WorkspaceID != "" {
+		if _, err := uuid.Parse(item.WorkspaceID); err != nil {
+			verr.Add("workspace_id", "must be a UUID")
+		}
+	}
+
+	if item.Position != "" && !ordering.Valid(item.Position) {
+		verr.Add("position", positionMessage)
+	}
+
+	if item.Type == "" {
+		verr.Add("type", "is required")
+		return verr
+	}
+
+	rule, ok := r.rules[item.Type]
+	if !ok {
+		if !r.allowUnknownTypes {
+			verr.Add("type", fmt.Sprintf("unknown item type %q", item.Type))
+			return verr
+		}
+		rule = r.defaults
+	}
+
+	if item.Encrypted() {
+		validateEncrypted(item, rule, verr)
+		return verr.OrNil()
+	}
+
+	if rule.RequireTitle && item.Title == "" {
+		verr.Add("title", "is required")
+	}
+	if rule.MaxTitleLength > 0 && utf8.RuneCountInString(item.Title) > rule.MaxTitleLength {
+		verr.Add("title", fmt.Sprintf("must be at most %d characters", rule.MaxTitleLength))
+	}
+
+	if rule.RequireContent && item.Content == "" {
+		verr.Add("content", "is required")
+	}
+	if rule.MaxContentBytes > 0 && len(item.Content) > rule.MaxContentBytes {
+		verr.Add("content", fmt.Sprintf("must be at most %d bytes", rule.MaxContentBytes))
+	}
+
+	if rule.ContentSchema != nil && item.Content != "" {
+		var doc any
+		if err := json.Unmarshal([]byte(item.Content), &doc); err != nil {
+			verr.Add("content", "must be valid JSON")
+		} else {
+			rule.ContentSchema.validate("content", doc, verr)
+		}
+	}
+
+	return verr.OrNil()
+}
+
+var positionMessage = fmt.Sprintf("must be 1-%d base62 characters not ending in 0", ordering.MaxLength)
+
+// ValidatePosition checks a fractional ordering key sent by a client.
+func ValidatePosition(position string) error {
+	if !ordering.Valid(position) {
+		return domain.NewValidationError(domain.FieldError{Field: "position", Message: positionMessage})
+	}
+	return nil
+}
+
+// EncryptionAlgorithms lists the accepted envelope algorithms and their nonce sizes in bytes.
+var EncryptionAlgorithms = map[string]int{
+	"AES-256-GCM":        12,
+	"XChaCha20-Poly1305": 24,
+}
+
+// authTagBytes is the authentication tag both algorithms append to a ciphertext.
+const authTagBytes = 16
+
+/*
+validateEncrypted checks the envelope and the shape of the ciphertext.
+
+The plaintext is unknown, so length limits apply to the decoded
+ciphertext (minus the tag) and content schemas are skipped.
+*/
+func validateEncrypted(item *domain.Item, rule Rule, verr *domain.ValidationError) {
+	enc := item.Encryption
+
+	if enc.KeyID == "" || len(enc.KeyID) > 128 {
+		verr.Add("encryption.key_id", "must be 1-128 bytes")
+	}
+
+	nonceSize, ok := EncryptionAlgorithms[enc.Algorithm]
+	if !ok {
+		verr.Add("encryption.algorithm", "must be AES-256-GCM or XChaCha20-Poly1305")
+	} else {
+		for _, n := range []struct{ field, value string }{
+			{"encryption.title_nonce", enc.TitleNonce},
+			{"encryption.content_nonce", enc.ContentNonce},
+		} {
+			raw, err := base64.StdEncoding.DecodeString(n.value)
+			if err != nil || len(raw) != nonceSize {
+				verr.Add(n.field, fmt.Sprintf("must be %d base64-encoded bytes", nonceSize))
+			}
+		}
+		if enc.TitleNonce != "" && enc.TitleNonce == enc.ContentNonce {
+			verr.Add("encryption.content_nonce", "must differ from title_nonce")
+		}
+	}
+
+	title, err := base64.StdEncoding.DecodeString(item.Title)
+	if err != nil {
+		verr.Add("title", "must be base64 ciphertext")
+	} else if rule.RequireTitle && len(title) <= authTagBytes {
+		verr.Add("title", "is required")
+	} else if rule.MaxTitleLength > 0 && len(title)-authTagBytes > rule.MaxTitleLength*utf8.UTFMax {
+		verr.Add("title", fmt.Sprintf("must be at most %d characters", rule.MaxTitleLength))
+	}
+
+	content, err := base64.StdEncoding.DecodeString(item.Content)
+	if err != nil {
+		verr.Add("content", "must be base64 ciphertext")
+	} else if rule.RequireContent && len(content) <= authTagBytes {
+		verr.Add("content", "is required")
+	} else if rule.MaxContentBytes > 0 && len(content)-authTagBytes > rule.MaxContentBytes {
+		verr.Add("content", fmt.Sprintf("must be at most %d bytes", rule.MaxContentBytes))
+	}
+}
@@ -0,0 +1,149 @@
+package validation
+
+import (
+	domain "Offline-First/internal/domain/model"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"unicode/utf8"
+)
+
+/*
+Schema is the subset of JSON Schema used for structured item content.
+
+Supported keywords: type, enum, required, properties,
+additionalProperties (boolean), items, minLength, maxLength,
+minimum, maximum, maxItems.
+*/
+type Schema struct {
+	Type                 string             `json:"type"`
+	Enum                 []any              `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+// check rejects null property schemas ("properties": {"x": null}), so a
+// broken config fails at load instead of on the first item of its type.
+func (s *Schema) check(path string) error {
+	for key, prop := range s.Properties {
+		if prop == nil {
+			return fmt.Errorf("%s.properties.%s: schema must not be null", path, key)
+		}
+		if err := prop.check(path + ".properties." + key); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.check(path + ".items")
+	}
+	return nil
+}
+
+func (s *Schema) validate(path string, v any, verr *domain.ValidationError) {
+	if s.Type != "" && !matchesType(s.Type, v) {
+		verr.Add(path, "must be of type "+s.Type)
+		return
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		verr.Add(path, "must be one of the allowed values")
+	}
+
+	switch val := v.(type) {
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			verr.Add(path, fmt.Sprintf("must be at least %d characters", *s.MinLength))
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			verr.Add(path, fmt.Sprintf("must be at most %d characters", *s.MaxLength))
+		}
+
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			verr.Add(path, fmt.Sprintf("must be >= %v", *s.Minimum))
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			verr.Add(path, fmt.Sprintf("must be <= %v", *s.Maximum))
+		}
+
+	case []any:
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			verr.Add(path, fmt.Sprintf("must have at most %d items", *s.MaxItems))
+		}
+		if s.Items != nil {
+			for i, elem := range val {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), elem, verr)
+			}
+		}
+
+	case map[string]any:
+		for _, key := range s.Required {
+			if _, ok := val[key]; !ok {
+				verr.Add(path+"."+key, "is required")
+			}
+		}
+
+		// deterministic error order for clients
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			prop, ok := s.Properties[key]
+			if !ok || prop == nil {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					verr.Add(path+"."+key, "is not allowed")
+				}
+				continue
+			}
+			prop.validate(path+"."+key, val[key], verr)
+		}
+	}
+}
+
+func matchesType(t string, v any) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return true
+	}
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, v) {
+			return true
+		}
+	}
+	return false
+}
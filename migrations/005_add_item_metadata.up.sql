@@ -0,0 +1,6 @@
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+-- supports GET /items?metadata.<key>=<value> and ?metadata_has=<key>
+CREATE INDEX IF NOT EXISTS idx_items_metadata
+ON items USING GIN (metadata);
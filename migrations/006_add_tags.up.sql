@@ -0,0 +1,41 @@
+-- mutation_log now records mutations for every synced entity, not only items
+ALTER TABLE mutation_log DROP CONSTRAINT IF EXISTS mutation_log_mutation_type_check;
+ALTER TABLE mutation_log RENAME COLUMN item_id TO entity_id;
+ALTER TABLE mutation_log ADD COLUMN IF NOT EXISTS entity_type TEXT NOT NULL DEFAULT 'item';
+ALTER TABLE mutation_log ADD COLUMN IF NOT EXISTS user_id UUID;
+
+ALTER INDEX IF EXISTS idx_mutation_log_item_id RENAME TO idx_mutation_log_entity_id;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id          UUID PRIMARY KEY,
+    user_id     UUID NOT NULL,
+
+    name        TEXT NOT NULL,
+
+    version     BIGINT NOT NULL,
+    deleted     BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tags_user_version
+ON tags(user_id, version);
+
+-- assignments are versioned rows too, so unassign is a tombstone
+CREATE TABLE IF NOT EXISTS item_tags (
+    item_id     UUID NOT NULL REFERENCES items(id),
+    tag_id      UUID NOT NULL REFERENCES tags(id),
+    user_id     UUID NOT NULL,
+
+    version     BIGINT NOT NULL,
+    deleted     BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_tags_user_version
+ON item_tags(user_id, version);
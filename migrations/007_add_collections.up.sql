@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id          UUID PRIMARY KEY,
+    user_id     UUID NOT NULL,
+
+    -- NULL = top level
+    parent_id   UUID REFERENCES collections(id),
+    name        TEXT NOT NULL,
+
+    version     BIGINT NOT NULL,
+    deleted     BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_collections_user_version
+ON collections(user_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_collections_parent
+ON collections(parent_id);
+
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS collection_id UUID REFERENCES collections(id);
+
+CREATE INDEX IF NOT EXISTS idx_items_collection
+ON items(collection_id);
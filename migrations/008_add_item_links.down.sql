@@ -0,0 +1 @@
+-- rollback not supported
@@ -0,0 +1,20 @@
+-- rebuilt from items.content on every create/update, inside the mutation transaction
+CREATE TABLE IF NOT EXISTS item_links (
+    source_id   UUID NOT NULL REFERENCES items(id),
+    user_id     UUID NOT NULL,
+
+    target_ref  TEXT NOT NULL,
+    -- resolved at write time; NULL if no item matched (yet)
+    target_id   UUID,
+
+    PRIMARY KEY (source_id, target_ref)
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_links_target
+ON item_links(user_id, target_id);
+
+CREATE INDEX IF NOT EXISTS idx_item_links_target_ref
+ON item_links(user_id, lower(target_ref));
+
+CREATE INDEX IF NOT EXISTS idx_items_user_title
+ON items(user_id, lower(title));
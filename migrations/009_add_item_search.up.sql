@@ -0,0 +1,10 @@
+-- maintained by Postgres on every write, so it can never lag behind content
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(content, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_items_search
+ON items USING GIN (search_vector);
@@ -0,0 +1,49 @@
+-- content-addressed, deduplicated per user
+CREATE TABLE IF NOT EXISTS blobs (
+    user_id       UUID NOT NULL,
+    sha256        TEXT NOT NULL,
+
+    size          BIGINT NOT NULL,
+    content_type  TEXT NOT NULL,
+
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (user_id, sha256)
+);
+
+-- resumable upload sessions; bytes live in the staging directory
+CREATE TABLE IF NOT EXISTS uploads (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+
+    sha256        TEXT NOT NULL,
+    size          BIGINT NOT NULL,
+    received      BIGINT NOT NULL DEFAULT 0,
+    content_type  TEXT NOT NULL,
+
+    created_at    TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+    item_id       UUID NOT NULL REFERENCES items(id),
+
+    blob_sha256   TEXT NOT NULL,
+    filename      TEXT NOT NULL,
+
+    version       BIGINT NOT NULL,
+    deleted       BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_user_version
+ON attachments(user_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_item
+ON attachments(item_id);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_blob
+ON attachments(user_id, blob_sha256);
@@ -0,0 +1,31 @@
+-- end-to-end encrypted items: title / content hold base64 ciphertext
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS encryption_key_id        TEXT,
+    ADD COLUMN IF NOT EXISTS encryption_algorithm     TEXT,
+    ADD COLUMN IF NOT EXISTS encryption_title_nonce   TEXT,
+    ADD COLUMN IF NOT EXISTS encryption_content_nonce TEXT;
+
+-- the envelope is all or nothing
+ALTER TABLE items
+    ADD CONSTRAINT items_encryption_envelope CHECK (
+        num_nulls(encryption_key_id, encryption_algorithm, encryption_title_nonce, encryption_content_nonce) IN (0, 4)
+    );
+
+-- ciphertext must never be indexed; a generated expression cannot be
+-- altered in place, so the column is rebuilt
+DROP INDEX IF EXISTS idx_items_search;
+ALTER TABLE items DROP COLUMN IF EXISTS search_vector;
+
+ALTER TABLE items
+    ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        CASE WHEN encryption_key_id IS NULL THEN
+            setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+            setweight(to_tsvector('english', coalesce(content, '')), 'B')
+        ELSE
+            ''::tsvector
+        END
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_items_search
+ON items USING GIN (search_vector);
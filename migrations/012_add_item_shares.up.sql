@@ -0,0 +1,22 @@
+-- one row per (item, collaborator); revoking keeps the row as a tombstone
+CREATE TABLE IF NOT EXISTS item_shares (
+    item_id     UUID NOT NULL REFERENCES items(id),
+    user_id     UUID NOT NULL,
+    owner_id    UUID NOT NULL,
+
+    role        TEXT NOT NULL CHECK (role IN ('editor', 'viewer')),
+
+    version     BIGINT NOT NULL,
+    revoked     BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (item_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_shares_user_version
+ON item_shares(user_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_item_shares_owner_version
+ON item_shares(owner_id, version);
@@ -0,0 +1,45 @@
+-- team vaults; a user's personal workspace has id = user_id and no row
+CREATE TABLE IF NOT EXISTS workspaces (
+    id              UUID PRIMARY KEY,
+    owner_id        UUID NOT NULL,
+    name            TEXT NOT NULL,
+
+    -- per-workspace counter for the versions of its items
+    latest_version  BIGINT NOT NULL DEFAULT 0,
+
+    -- global version of the workspace row itself
+    version         BIGINT NOT NULL,
+
+    updated_at      TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- removing a member keeps the row as a tombstone
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id  UUID NOT NULL REFERENCES workspaces(id),
+    user_id       UUID NOT NULL,
+
+    role          TEXT NOT NULL CHECK (role IN ('owner', 'editor', 'viewer')),
+
+    version       BIGINT NOT NULL,
+    removed       BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at    TIMESTAMP NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user
+ON workspace_members(user_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_version
+ON workspace_members(workspace_id, version);
+
+-- existing items move into their owner's personal workspace
+ALTER TABLE items ADD COLUMN IF NOT EXISTS workspace_id UUID;
+UPDATE items SET workspace_id = user_id WHERE workspace_id IS NULL;
+ALTER TABLE items ALTER COLUMN workspace_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_items_workspace_version
+ON items(workspace_id, version);
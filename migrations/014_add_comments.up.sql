@@ -0,0 +1,21 @@
+-- threaded comments; versioned with the item's workspace counter
+CREATE TABLE IF NOT EXISTS comments (
+    id          UUID PRIMARY KEY,
+    item_id     UUID NOT NULL REFERENCES items(id),
+    user_id     UUID NOT NULL,
+    parent_id   UUID REFERENCES comments(id),
+
+    body        TEXT NOT NULL,
+
+    version     BIGINT NOT NULL,
+    deleted     BOOLEAN NOT NULL DEFAULT FALSE,
+
+    updated_at  TIMESTAMP NOT NULL,
+    created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_item_version
+ON comments(item_id, version);
+
+CREATE INDEX IF NOT EXISTS idx_comments_parent
+ON comments(parent_id);
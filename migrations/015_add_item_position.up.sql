@@ -0,0 +1,7 @@
+-- manual ordering: fractional base62 keys, compared bytewise;
+-- '' means the item was never placed
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS position TEXT COLLATE "C" NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_items_position
+ON items(workspace_id, collection_id, position, id);
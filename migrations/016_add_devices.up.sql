@@ -0,0 +1,28 @@
+-- registered devices; revoking one invalidates its access and refresh tokens
+CREATE TABLE IF NOT EXISTS devices (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+    name          TEXT NOT NULL,
+
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    last_seen_at  TIMESTAMP NOT NULL DEFAULT now(),
+    revoked_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_devices_user
+ON devices(user_id);
+
+-- rotating refresh tokens; only the SHA-256 of the secret is stored and
+-- a used token is kept so replaying it can be detected
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id          UUID PRIMARY KEY,
+    device_id   UUID NOT NULL REFERENCES devices(id),
+    token_hash  TEXT NOT NULL,
+
+    expires_at  TIMESTAMP NOT NULL,
+    used_at     TIMESTAMP,
+    created_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_device
+ON refresh_tokens(device_id);
@@ -0,0 +1,16 @@
+-- per-user API keys; only the SHA-256 of the secret is stored
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+    name          TEXT NOT NULL,
+    prefix        TEXT NOT NULL UNIQUE,
+    secret_hash   TEXT NOT NULL,
+    scopes        TEXT[] NOT NULL DEFAULT '{}',
+
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    last_used_at  TIMESTAMP,
+    revoked_at    TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user
+ON api_keys(user_id);
@@ -0,0 +1,31 @@
+-- storage per user, maintained by the mutations that change it
+CREATE TABLE IF NOT EXISTS user_usage (
+    user_id           UUID PRIMARY KEY,
+
+    item_count        BIGINT NOT NULL DEFAULT 0,
+    content_bytes     BIGINT NOT NULL DEFAULT 0,
+    attachment_bytes  BIGINT NOT NULL DEFAULT 0,
+
+    updated_at        TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- backfill from what is stored today
+INSERT INTO user_usage (user_id, item_count, content_bytes, attachment_bytes)
+SELECT user_id, SUM(item_count), SUM(content_bytes), SUM(attachment_bytes)
+FROM (
+    SELECT user_id,
+        COUNT(*) AS item_count,
+        SUM(octet_length(title) + octet_length(content)) AS content_bytes,
+        0 AS attachment_bytes
+    FROM items
+    WHERE deleted = false
+    GROUP BY user_id
+
+    UNION ALL
+
+    SELECT user_id, 0, 0, SUM(size)
+    FROM blobs
+    GROUP BY user_id
+) usage
+GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
@@ -0,0 +1,49 @@
+-- append-only record of security-relevant events
+CREATE TABLE IF NOT EXISTS audit_log (
+    id           BIGSERIAL PRIMARY KEY,
+    occurred_at  TIMESTAMP NOT NULL DEFAULT now(),
+
+    category     TEXT NOT NULL CHECK (category IN ('auth', 'mutation', 'permission', 'credential', 'admin')),
+    action       TEXT NOT NULL,
+    outcome      TEXT NOT NULL CHECK (outcome IN ('success', 'failure')),
+
+    -- who
+    user_id      UUID,
+    device_id    UUID,
+    api_key_id   UUID,
+    remote_addr  TEXT,
+
+    -- what
+    entity_type  TEXT,
+    entity_id    TEXT,
+    mutation_id  UUID,
+    version      BIGINT,
+
+    detail       JSONB NOT NULL DEFAULT '{}'::jsonb
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_user
+ON audit_log(user_id, id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_category
+ON audit_log(category, id);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_occurred_at
+ON audit_log(occurred_at);
+
+-- rows can be added, never changed or removed
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_no_update ON audit_log;
+CREATE TRIGGER audit_log_no_update
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+DROP TRIGGER IF EXISTS audit_log_no_truncate ON audit_log;
+CREATE TRIGGER audit_log_no_truncate
+BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
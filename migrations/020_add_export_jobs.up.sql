@@ -0,0 +1,21 @@
+-- account exports built in the background; archives live in EXPORT_DIR
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id            UUID PRIMARY KEY,
+    user_id       UUID NOT NULL,
+
+    status        TEXT NOT NULL CHECK (status IN ('pending', 'running', 'complete', 'failed')),
+    error         TEXT,
+    size          BIGINT,
+
+    created_at    TIMESTAMP NOT NULL DEFAULT now(),
+    started_at    TIMESTAMP,
+    completed_at  TIMESTAMP,
+    expires_at    TIMESTAMP
+);
+
+-- one export in flight per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_export_jobs_active
+ON export_jobs(user_id) WHERE status IN ('pending', 'running');
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_status
+ON export_jobs(status, created_at);
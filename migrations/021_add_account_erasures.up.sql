@@ -0,0 +1,28 @@
+-- proof that an account was erased; the user ID itself is not kept
+CREATE TABLE IF NOT EXISTS account_erasures (
+    subject_hash  TEXT PRIMARY KEY,     -- hex SHA-256 of the user ID
+    erased_at     TIMESTAMP NOT NULL DEFAULT now(),
+    deleted_rows  JSONB NOT NULL DEFAULT '{}'::jsonb
+);
+
+-- refresh tokens still usable at erasure, so a device presenting one
+-- learns its account is gone instead of getting a generic 401
+CREATE TABLE IF NOT EXISTS erased_refresh_tokens (
+    id_hash    TEXT PRIMARY KEY,        -- hex SHA-256 of the token ID
+    erased_at  TIMESTAMP NOT NULL DEFAULT now()
+);
+
+ALTER TABLE audit_log DROP CONSTRAINT IF EXISTS audit_log_category_check;
+ALTER TABLE audit_log ADD CONSTRAINT audit_log_category_check
+    CHECK (category IN ('auth', 'mutation', 'permission', 'credential', 'admin', 'account'));
+
+-- an account erasure is the only way rows leave the log; it opts in
+-- with SET LOCAL app.erasure = 'on' inside its transaction
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' AND current_setting('app.erasure', true) = 'on' THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,6 @@
+-- a purged item is a tombstone whose content was removed for good
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS purged BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE items
+    ADD CONSTRAINT items_purged_deleted CHECK (deleted OR NOT purged);
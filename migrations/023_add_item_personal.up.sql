@@ -0,0 +1,29 @@
+-- personal items are marked explicitly instead of being inferred from
+-- workspace_id = user_id, so a workspace row can never claim them
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS personal BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE items SET personal = true WHERE workspace_id = user_id;
+
+ALTER TABLE items
+    ADD CONSTRAINT items_personal_owner CHECK (NOT personal OR workspace_id = user_id);
+
+-- team workspace IDs are generated by the server; this keeps any other
+-- writer from taking over a personal workspace ID
+ALTER TABLE workspaces
+    ADD CONSTRAINT workspaces_not_owner CHECK (id <> owner_id);
+
+CREATE OR REPLACE FUNCTION workspaces_not_personal() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM items WHERE workspace_id = NEW.id AND personal) THEN
+        RAISE EXCEPTION 'workspace id % is a personal workspace', NEW.id
+            USING ERRCODE = 'unique_violation';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS workspaces_not_personal ON workspaces;
+CREATE TRIGGER workspaces_not_personal
+BEFORE INSERT ON workspaces
+FOR EACH ROW EXECUTE FUNCTION workspaces_not_personal();